@@ -241,6 +241,9 @@ func (f *Factory) Generate(settingsName string, imports *Imports, config *action
 		if step.HaltCondition != "" {
 			code += fmt.Sprintf("step%d.SetHalt(\"%s\")\n", i, step.HaltCondition)
 		}
+		if step.Mirror != nil {
+			code += fmt.Sprintf("step%d.SetMirror(%s, %#v, %t)\n", i, services[step.Mirror.Service], step.Mirror.Percentage, step.Mirror.Compare)
+		}
 		code += fmt.Sprintf("_ = step%d\n", i)
 	}
 	for i, response := range actionData.Responses {
@@ -418,6 +421,24 @@ func (f *Factory) New(config *action.Config) (action.Action, error) {
 			}
 			microgateway.Steps[j].HaltCondition = core.NewExpr("halt", condition, expr)
 		}
+
+		if mirror := steps[j].Mirror; mirror != nil {
+			service := services[mirror.Service]
+			if service == nil {
+				return nil, fmt.Errorf("mirror service not found: %s", mirror.Service)
+			}
+			percentage := mirror.Percentage
+			if percentage == 0 {
+				percentage = 100
+			} else if percentage < 0 || percentage > 100 {
+				return nil, fmt.Errorf("invalid mirror percentage: %v", percentage)
+			}
+			microgateway.Steps[j].Mirror = &core.Mirror{
+				Service:    service,
+				Percentage: percentage,
+				Compare:    mirror.Compare,
+			}
+		}
 	}
 
 	for j := range responses {
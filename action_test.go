@@ -2,6 +2,7 @@ package microgateway
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
@@ -12,12 +13,14 @@ import (
 	"github.com/project-flogo/core/api"
 	"github.com/project-flogo/core/engine/channels"
 	microapi "github.com/project-flogo/microgateway/api"
+	"github.com/project-flogo/microgateway/internal/schema"
 	"github.com/project-flogo/microgateway/internal/testing/activity"
 	"github.com/project-flogo/microgateway/internal/testing/trigger"
 	"github.com/stretchr/testify/assert"
 
 	_ "github.com/project-flogo/contrib/activity/channel"
 	_ "github.com/project-flogo/contrib/activity/rest"
+	"github.com/project-flogo/microgateway/activity/aggregate"
 	_ "github.com/project-flogo/microgateway/activity/circuitbreaker"
 	_ "github.com/project-flogo/microgateway/activity/jwt"
 	_ "github.com/project-flogo/microgateway/activity/ratelimiter"
@@ -213,6 +216,178 @@ func TestMicrogatewayHandler(t *testing.T) {
 	assert.False(t, defaultActionHit)
 }
 
+func TestMicrogatewayDefer(t *testing.T) {
+	defer func() {
+		microapi.ClearResources()
+		trigger.Reset()
+		activity.Reset()
+	}()
+	app := api.NewApp()
+
+	deferred := 0
+	microgateway := microapi.New("defer")
+	serviceDefer := microgateway.NewService("defer", func(ctx coreactivity.Context) (done bool, err error) {
+		deferrer, ok := ctx.ActivityHost().(microapi.Deferrer)
+		assert.True(t, ok)
+		deferrer.Defer(func() {
+			deferred++
+		})
+		return true, nil
+	})
+	serviceFail := microgateway.NewService("fail", func(ctx coreactivity.Context) (done bool, err error) {
+		assert.Equal(t, 0, deferred)
+		return true, fmt.Errorf("failed")
+	})
+	microgateway.NewStep(serviceDefer)
+	microgateway.NewStep(serviceFail)
+	response := microgateway.NewResponse(true)
+	response.SetCode(500)
+	response.SetData("=error.string($.fail.error)")
+	settings, err := microgateway.AddResource(app)
+	assert.Nil(t, err)
+
+	trg := app.NewTrigger(&trigger.Trigger{}, &trigger.Settings{ASetting: 1337})
+	handler, err := trg.NewHandler(&trigger.HandlerSettings{})
+	assert.Nil(t, err)
+	_, err = handler.NewAction(&Action{}, settings)
+	assert.Nil(t, err)
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	e.Start()
+	defer e.Stop()
+
+	result, err := trigger.Fire(0, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 500, result["code"])
+	assert.Equal(t, "failed", result["data"])
+	assert.Equal(t, 1, deferred)
+}
+
+func TestMicrogatewayMirror(t *testing.T) {
+	defer func() {
+		microapi.ClearResources()
+		trigger.Reset()
+		activity.Reset()
+	}()
+	app := api.NewApp()
+
+	mirrored := make(chan string, 1)
+	microgateway := microapi.New("mirror")
+	servicePrimary := microgateway.NewService("primary", func(ctx coreactivity.Context) (done bool, err error) {
+		ctx.SetOutput("data", fmt.Sprintf("primary %v", ctx.GetInput("message")))
+		return true, nil
+	})
+	serviceShadow := microgateway.NewService("shadow", func(ctx coreactivity.Context) (done bool, err error) {
+		ctx.ActivityHost().Return(nil, nil)
+		mirrored <- fmt.Sprintf("%v %v", ctx.GetInput("message"), ctx.GetInput("setting"))
+		return true, fmt.Errorf("shadow failed")
+	})
+	serviceShadow.AddSetting("setting", "shadow")
+	serviceTest := microgateway.NewService("test", &activity.Activity{})
+	serviceTest.AddSetting("message", "hello world")
+	step := microgateway.NewStep(servicePrimary)
+	step.AddInput("message", "=$.payload.content.message")
+	step.SetMirror(serviceShadow, 0, true)
+	microgateway.NewStep(serviceTest)
+	response := microgateway.NewResponse(false)
+	response.SetIf("$.primary.error == nil")
+	response.SetCode(200)
+	response.SetData("=$.primary.outputs.data")
+	response = microgateway.NewResponse(true)
+	response.SetCode(500)
+	response.SetData("failed")
+
+	definition, err := json.Marshal(microgateway)
+	assert.Nil(t, err)
+	assert.Nil(t, schema.Validate(definition))
+
+	settings, err := microgateway.AddResource(app)
+	assert.Nil(t, err)
+
+	trg := app.NewTrigger(&trigger.Trigger{}, &trigger.Settings{ASetting: 1337})
+	handler, err := trg.NewHandler(&trigger.HandlerSettings{})
+	assert.Nil(t, err)
+	_, err = handler.NewAction(&Action{}, settings)
+	assert.Nil(t, err)
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	e.Start()
+	defer e.Stop()
+
+	result, err := trigger.Fire(0, map[string]interface{}{"message": "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result["code"])
+	assert.Equal(t, "primary hello", result["data"])
+	assert.True(t, activity.HasEvaled, "mirror should not halt the steps")
+	select {
+	case message := <-mirrored:
+		assert.Equal(t, "hello shadow", message)
+	case <-time.After(time.Second):
+		t.Fatal("service was not mirrored")
+	}
+}
+
+func TestMicrogatewayAggregate(t *testing.T) {
+	defer func() {
+		microapi.ClearResources()
+		trigger.Reset()
+		activity.Reset()
+	}()
+	app := api.NewApp()
+
+	microgateway := microapi.New("aggregate")
+	servicePets := microgateway.NewService("pets", func(ctx coreactivity.Context) (done bool, err error) {
+		ctx.SetOutput("data", map[string]interface{}{"name": "sally"})
+		return true, nil
+	})
+	serviceOwners := microgateway.NewService("owners", func(ctx coreactivity.Context) (done bool, err error) {
+		return true, fmt.Errorf("connection refused")
+	})
+	serviceProfile := microgateway.NewService("profile", &aggregate.Activity{})
+	serviceProfile.AddSetting("mode", "nest")
+	serviceProfile.AddSetting("sources", []interface{}{
+		map[string]interface{}{"name": "pet", "service": "pets", "required": true},
+		map[string]interface{}{"name": "owner", "service": "owners"},
+	})
+	step := microgateway.NewStep(servicePets)
+	step.SetHalt("$.pets.error != nil")
+	step = microgateway.NewStep(serviceOwners)
+	// a halt condition which is false continues the steps past a service error
+	step.SetHalt("false")
+	microgateway.NewStep(serviceProfile)
+	response := microgateway.NewResponse(false)
+	response.SetIf("$.profile.outputs.complete == false")
+	response.SetCode(206)
+	response.SetData("=$.profile.outputs.data")
+	response = microgateway.NewResponse(true)
+	response.SetCode(502)
+	response.SetData("failed")
+
+	settings, err := microgateway.AddResource(app)
+	assert.Nil(t, err)
+
+	trg := app.NewTrigger(&trigger.Trigger{}, &trigger.Settings{ASetting: 1337})
+	handler, err := trg.NewHandler(&trigger.HandlerSettings{})
+	assert.Nil(t, err)
+	_, err = handler.NewAction(&Action{}, settings)
+	assert.Nil(t, err)
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	e.Start()
+	defer e.Stop()
+
+	result, err := trigger.Fire(0, map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.Equal(t, 206, result["code"])
+	assert.Equal(t, map[string]interface{}{
+		"pet":   map[string]interface{}{"name": "sally"},
+		"owner": nil,
+	}, result["data"])
+}
+
 type handler struct {
 	hit bool
 }
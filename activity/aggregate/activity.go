@@ -0,0 +1,153 @@
+package aggregate
+
+import (
+	"fmt"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new aggregator
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{
+		Mode:    ModeMerge,
+		OnError: OnErrorMark,
+	}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	sources, err := ParseSources(settings.Sources)
+	if err != nil {
+		return nil, err
+	}
+	act := &Activity{
+		sources: sources,
+		mode:    settings.Mode,
+		onError: settings.OnError,
+		marker:  settings.Marker,
+	}
+	return act, nil
+}
+
+// Activity is an aggregator which combines the outputs of services
+type Activity struct {
+	sources []*Source
+	mode    string
+	onError string
+	marker  interface{}
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	scope := ctx.ActivityHost().Scope()
+	outputs, failures := make(map[string]interface{}, len(a.sources)), make(map[string]string)
+	output := Output{}
+	for _, source := range a.sources {
+		var service interface{}
+		if scope != nil {
+			service, _ = scope.GetValue(source.Service)
+		}
+		value, err := source.Resolve(service)
+		if err == nil && a.mode == ModeZip {
+			if _, ok := value.([]interface{}); !ok {
+				err = fmt.Errorf("output %s of service %s is not an array", source.Output, source.Service)
+			}
+		}
+		if err != nil {
+			failures[source.Name] = err.Error()
+			if source.Required || a.onError == OnErrorFail {
+				output.Error = true
+				output.ErrorMessage = fmt.Sprintf("source %s failed: %v", source.Name, err)
+				break
+			}
+			continue
+		}
+		outputs[source.Name] = value
+	}
+	output.Failures = failures
+	output.Complete = len(failures) == 0
+	if !output.Error {
+		output.Data = a.aggregate(input.Base, outputs, failures)
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// aggregate combines the outputs of the sources, the failed sources are marked or omitted
+func (a *Activity) aggregate(base interface{}, outputs map[string]interface{}, failures map[string]string) interface{} {
+	mark := a.onError == OnErrorMark
+	switch a.mode {
+	case ModeZip:
+		names, arrays := make([]string, 0, len(a.sources)), make(map[string][]interface{}, len(outputs))
+		for _, source := range a.sources {
+			if output, ok := outputs[source.Name]; ok {
+				arrays[source.Name] = output.([]interface{})
+			} else if !mark {
+				continue
+			}
+			names = append(names, source.Name)
+		}
+		result := Zip(names, arrays)
+		if mark {
+			for _, element := range result {
+				for name := range failures {
+					element.(map[string]interface{})[name] = a.marker
+				}
+			}
+		}
+		return result
+	case ModeNest:
+		result := Merge(nil, base)
+		object, ok := result.(map[string]interface{})
+		if !ok {
+			object = make(map[string]interface{}, len(a.sources))
+		}
+		for _, source := range a.sources {
+			if output, ok := outputs[source.Name]; ok {
+				object[source.Name] = output
+			} else if mark {
+				object[source.Name] = a.marker
+			}
+		}
+		return object
+	}
+
+	// the base is copied so that the outputs are never merged into the input
+	result := Merge(nil, base)
+	for _, source := range a.sources {
+		if output, ok := outputs[source.Name]; ok {
+			result = Merge(result, output)
+		} else if mark {
+			result = Merge(result, map[string]interface{}{source.Name: a.marker})
+		}
+	}
+	return result
+}
@@ -0,0 +1,303 @@
+package aggregate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+	scope  data.Scope
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return a.scope
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func service(outputs map[string]interface{}, err error) map[string]interface{} {
+	return map[string]interface{}{
+		"inputs":  map[string]interface{}{},
+		"outputs": outputs,
+		"error":   err,
+	}
+}
+
+func newScope() data.Scope {
+	return data.NewSimpleScope(map[string]interface{}{
+		"Pets": service(map[string]interface{}{
+			"status": 200,
+			"data": map[string]interface{}{
+				"name": "sally",
+				"tags": map[string]interface{}{"color": "brown"},
+			},
+		}, nil),
+		"Owners": service(map[string]interface{}{
+			"status": 200,
+			"data": map[string]interface{}{
+				"owner": "bob",
+				"tags":  map[string]interface{}{"size": "small"},
+			},
+		}, nil),
+		"Prices": service(map[string]interface{}{
+			"data": map[string]interface{}{
+				"items": []interface{}{1.5, 2.5, 3.5},
+			},
+		}, nil),
+		"Stock": service(map[string]interface{}{
+			"data": []interface{}{"in", "out"},
+		}, nil),
+		"Reviews": service(map[string]interface{}{}, errors.New("connection refused")),
+	}, nil)
+}
+
+func aggregate(t *testing.T, settings, input map[string]interface{}) map[string]interface{} {
+	act, err := New(newInitContext(settings))
+	assert.Nil(t, err)
+	ctx := newActivityContext(input)
+	ctx.scope = newScope()
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func TestAggregate(t *testing.T) {
+	output := aggregate(t, map[string]interface{}{
+		"sources": []interface{}{"Pets", "Owners"},
+	}, map[string]interface{}{
+		"base": map[string]interface{}{"id": 1, "tags": map[string]interface{}{"kind": "dog"}},
+	})
+	assert.Equal(t, map[string]interface{}{
+		"id":    1,
+		"name":  "sally",
+		"owner": "bob",
+		"tags":  map[string]interface{}{"kind": "dog", "color": "brown", "size": "small"},
+	}, output["data"])
+	assert.Equal(t, true, output["complete"])
+	assert.Equal(t, false, output["error"])
+
+	output = aggregate(t, map[string]interface{}{
+		"mode": "nest",
+		"sources": []interface{}{
+			"Pets",
+			map[string]interface{}{"name": "prices", "service": "Prices", "output": "data.items"},
+			map[string]interface{}{"name": "status", "service": "Owners", "output": "status"},
+		},
+	}, nil)
+	assert.Equal(t, map[string]interface{}{
+		"Pets": map[string]interface{}{
+			"name": "sally",
+			"tags": map[string]interface{}{"color": "brown"},
+		},
+		"prices": []interface{}{1.5, 2.5, 3.5},
+		"status": 200,
+	}, output["data"])
+
+	output = aggregate(t, map[string]interface{}{
+		"mode": "zip",
+		"sources": []interface{}{
+			map[string]interface{}{"name": "price", "service": "Prices", "output": "data.items"},
+			map[string]interface{}{"name": "stock", "service": "Stock"},
+		},
+	}, nil)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"price": 1.5, "stock": "in"},
+		map[string]interface{}{"price": 2.5, "stock": "out"},
+		map[string]interface{}{"price": 3.5, "stock": nil},
+	}, output["data"])
+	assert.Equal(t, true, output["complete"])
+
+	output = aggregate(t, map[string]interface{}{
+		"mode":    "zip",
+		"sources": []interface{}{"Pets", "Stock"},
+	}, nil)
+	assert.Equal(t, false, output["complete"])
+	assert.Equal(t, map[string]string{"Pets": "output data of service Pets is not an array"}, output["failures"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"Pets": nil, "Stock": "in"},
+		map[string]interface{}{"Pets": nil, "Stock": "out"},
+	}, output["data"])
+
+	for _, settings := range []map[string]interface{}{
+		{"sources": []interface{}{}},
+		{"sources": []interface{}{map[string]interface{}{"name": "a"}}},
+		{"sources": []interface{}{map[string]interface{}{"service": "Pets", "unknown": true}}},
+		{"sources": []interface{}{"Pets", "Pets"}},
+		{"sources": []interface{}{"Pets"}, "mode": "join"},
+	} {
+		_, err := New(newInitContext(settings))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestAggregatePartialFailure(t *testing.T) {
+	sources := []interface{}{"Pets", "Reviews", "Missing"}
+
+	output := aggregate(t, map[string]interface{}{
+		"mode":    "nest",
+		"sources": sources,
+		"marker":  map[string]interface{}{"unavailable": true},
+	}, nil)
+	marker := map[string]interface{}{"unavailable": true}
+	data := output["data"].(map[string]interface{})
+	assert.Equal(t, marker, data["Reviews"])
+	assert.Equal(t, marker, data["Missing"])
+	assert.NotNil(t, data["Pets"])
+	assert.Equal(t, false, output["complete"])
+	assert.Equal(t, false, output["error"])
+	assert.Equal(t, map[string]string{
+		"Reviews": "connection refused",
+		"Missing": "service Missing not found",
+	}, output["failures"])
+
+	output = aggregate(t, map[string]interface{}{
+		"sources": sources,
+	}, nil)
+	assert.Equal(t, map[string]interface{}{
+		"name":    "sally",
+		"tags":    map[string]interface{}{"color": "brown"},
+		"Reviews": nil,
+		"Missing": nil,
+	}, output["data"])
+
+	output = aggregate(t, map[string]interface{}{
+		"mode":    "nest",
+		"onError": "omit",
+		"sources": sources,
+	}, nil)
+	assert.Equal(t, map[string]interface{}{
+		"Pets": map[string]interface{}{
+			"name": "sally",
+			"tags": map[string]interface{}{"color": "brown"},
+		},
+	}, output["data"])
+	assert.Equal(t, false, output["complete"])
+
+	output = aggregate(t, map[string]interface{}{
+		"onError": "fail",
+		"sources": sources,
+	}, nil)
+	assert.Equal(t, true, output["error"])
+	assert.Equal(t, "source Reviews failed: connection refused", output["errorMessage"])
+	assert.Nil(t, output["data"])
+
+	output = aggregate(t, map[string]interface{}{
+		"onError": "omit",
+		"sources": []interface{}{
+			"Pets",
+			map[string]interface{}{"service": "Owners", "output": "headers", "required": true},
+		},
+	}, nil)
+	assert.Equal(t, true, output["error"])
+	assert.Equal(t, "source Owners failed: service Owners has no output headers", output["errorMessage"])
+}
+
+func TestMerge(t *testing.T) {
+	base := map[string]interface{}{"a": map[string]interface{}{"b": 1}}
+	src := map[string]interface{}{"a": map[string]interface{}{"c": 2}, "d": []interface{}{3}}
+	result := Merge(Merge(nil, base), src)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"b": 1, "c": 2},
+		"d": []interface{}{3},
+	}, result)
+	assert.Equal(t, map[string]interface{}{"a": map[string]interface{}{"b": 1}}, base)
+	assert.Equal(t, map[string]interface{}{"a": map[string]interface{}{"c": 2}, "d": []interface{}{3}}, src)
+	assert.Equal(t, "value", Merge(base, "value"))
+}
@@ -0,0 +1,158 @@
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/project-flogo/core/data/coerce"
+)
+
+const (
+	// ModeMerge deep merges the outputs of the sources
+	ModeMerge = "merge"
+	// ModeNest nests the output of each source under its name
+	ModeNest = "nest"
+	// ModeZip combines the elements of the array outputs of the sources by index
+	ModeZip = "zip"
+	// OnErrorMark replaces the output of a failed source with the marker
+	OnErrorMark = "mark"
+	// OnErrorOmit leaves out the output of a failed source
+	OnErrorOmit = "omit"
+	// OnErrorFail fails the aggregation when a source fails
+	OnErrorFail = "fail"
+	// DefaultOutput is the default output of a service used by a source
+	DefaultOutput = "data"
+)
+
+// Source is a service output to aggregate
+type Source struct {
+	Name     string
+	Service  string
+	Output   string
+	Required bool
+}
+
+// ParseSources parses the sources from the settings
+func ParseSources(values []interface{}) ([]*Source, error) {
+	sources := make([]*Source, 0, len(values))
+	names := make(map[string]bool, len(values))
+	for i, value := range values {
+		source := &Source{}
+		if s, ok := value.(string); ok {
+			source.Service = s
+		} else {
+			settings, err := coerce.ToObject(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid source %d: %v", i, err)
+			}
+			for key, setting := range settings {
+				switch key {
+				case "name":
+					source.Name, err = coerce.ToString(setting)
+				case "service":
+					source.Service, err = coerce.ToString(setting)
+				case "output":
+					source.Output, err = coerce.ToString(setting)
+				case "required":
+					source.Required, err = coerce.ToBool(setting)
+				default:
+					err = fmt.Errorf("unknown field '%s'", key)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("invalid source %d: %v", i, err)
+				}
+			}
+		}
+		if source.Service == "" {
+			return nil, fmt.Errorf("source %d needs a service", i)
+		}
+		if source.Name == "" {
+			source.Name = source.Service
+		}
+		if source.Output == "" {
+			source.Output = DefaultOutput
+		}
+		if names[source.Name] {
+			return nil, fmt.Errorf("duplicate source: %s", source.Name)
+		}
+		names[source.Name] = true
+		sources = append(sources, source)
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("at least one source is required")
+	}
+	return sources, nil
+}
+
+// Resolve returns the output of the source from the value of its service in the scope
+// of the microgateway, which holds the inputs, outputs and error of the service
+func (s *Source) Resolve(service interface{}) (interface{}, error) {
+	values, ok := service.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("service %s not found", s.Service)
+	}
+	if err, ok := values["error"].(error); ok && err != nil {
+		return nil, err
+	}
+	var value interface{} = values["outputs"]
+	for _, key := range strings.Split(s.Output, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("service %s has no output %s", s.Service, s.Output)
+		}
+		value, ok = object[key]
+		if !ok {
+			return nil, fmt.Errorf("service %s has no output %s", s.Service, s.Output)
+		}
+	}
+	return value, nil
+}
+
+// Merge deep merges src into dst and returns the result. Objects are merged key by key,
+// other values of src replace the values of dst. The values of src are never modified
+func Merge(dst, src interface{}) interface{} {
+	srcObject, ok := src.(map[string]interface{})
+	if !ok {
+		return src
+	}
+	dstObject, ok := dst.(map[string]interface{})
+	if !ok {
+		dstObject = make(map[string]interface{}, len(srcObject))
+	}
+	for key, value := range srcObject {
+		if _, ok := value.(map[string]interface{}); ok {
+			value = Merge(dstObject[key], value)
+		}
+		dstObject[key] = value
+	}
+	return dstObject
+}
+
+// Zip combines the arrays of the sources into an array of objects, the element i of the result holds
+// the element i of each array under the name of its source. The result is as long as the longest array
+func Zip(names []string, arrays map[string][]interface{}) []interface{} {
+	length := 0
+	for _, array := range arrays {
+		if len(array) > length {
+			length = len(array)
+		}
+	}
+	result := make([]interface{}, length)
+	for i := range result {
+		element := make(map[string]interface{}, len(names))
+		for _, name := range names {
+			array, ok := arrays[name]
+			if !ok {
+				continue
+			}
+			if i < len(array) {
+				element[name] = array[i]
+			} else {
+				element[name] = nil
+			}
+		}
+		result[i] = element
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package aggregate
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the aggregator
+type Settings struct {
+	Sources []interface{} `md:"sources,required"`
+	Mode    string        `md:"mode,allowed(merge,nest,zip)"`
+	OnError string        `md:"onError,allowed(mark,omit,fail)"`
+	Marker  interface{}   `md:"marker"`
+}
+
+// Input is the input for the aggregator
+type Input struct {
+	Base interface{} `md:"base"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	r.Base = values["base"]
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"base": r.Base,
+	}
+}
+
+// Output is the output of the aggregator
+type Output struct {
+	Data         interface{}       `md:"data"`
+	Complete     bool              `md:"complete"`
+	Failures     map[string]string `md:"failures"`
+	Error        bool              `md:"error"`
+	ErrorMessage string            `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	o.Data = values["data"]
+	complete, err := coerce.ToBool(values["complete"])
+	if err != nil {
+		return err
+	}
+	o.Complete = complete
+	failures, err := coerce.ToParams(values["failures"])
+	if err != nil {
+		return err
+	}
+	o.Failures = failures
+	hasError, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = hasError
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data":         o.Data,
+		"complete":     o.Complete,
+		"failures":     o.Failures,
+		"error":        o.Error,
+		"errorMessage": o.ErrorMessage,
+	}
+}
@@ -0,0 +1,157 @@
+package apikey
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+const (
+	// DefaultHeader is the default header holding the API key
+	DefaultHeader = "X-API-Key"
+)
+
+var (
+	// ErrorKeyNotFound happens when there is no API key in the request
+	ErrorKeyNotFound = errors.New("API key not found")
+	// ErrorUnknownKey happens when the API key isn't in the store
+	ErrorUnknownKey = errors.New("unknown API key")
+	// ErrorKeyExpired happens when the API key has expired
+	ErrorKeyExpired = errors.New("API key expired")
+	// ErrorKeyRevoked happens when the API key has been revoked
+	ErrorKeyRevoked = errors.New("API key revoked")
+	// ErrorRouteNotAllowed happens when the API key isn't allowed to access the route
+	ErrorRouteNotAllowed = errors.New("route not allowed for API key")
+	activityMetadata     = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new API key validator
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := NewStore(settings.Store, settings.StoreURL)
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{
+		header: settings.Header,
+		query:  settings.Query,
+		store:  store,
+	}
+	if act.header == "" {
+		act.header = DefaultHeader
+	}
+	return act, nil
+}
+
+// Activity is an API key validator
+type Activity struct {
+	header string
+	query  string
+	store  Store
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := Output{}
+	key, err := a.validate(&input, time.Now())
+	switch err {
+	case nil:
+		output.Valid = true
+		output.Owner, output.Plan, output.Metadata = key.Owner, key.Plan, key.Metadata
+	case ErrorKeyNotFound, ErrorUnknownKey, ErrorKeyExpired, ErrorKeyRevoked, ErrorRouteNotAllowed:
+		output.ValidationMessage = err.Error()
+	default:
+		ctx.Logger().Errorf("error looking up API key: %v", err)
+		output.ValidationMessage = err.Error()
+		output.Error = true
+		output.ErrorMessage = err.Error()
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// validate looks up the key of a request and checks that it can be used
+func (a *Activity) validate(input *Input, now time.Time) (*Key, error) {
+	value := a.extractKey(input)
+	if value == "" {
+		return nil, ErrorKeyNotFound
+	}
+	key, err := a.store.Lookup(HashKey(value))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrorUnknownKey
+	}
+	if key.Revoked {
+		return key, ErrorKeyRevoked
+	}
+	if !key.Expires.IsZero() && now.After(key.Expires) {
+		return key, ErrorKeyExpired
+	}
+	if len(key.Routes) > 0 && !allowed(key.Routes, input.Route) {
+		return key, ErrorRouteNotAllowed
+	}
+	return key, nil
+}
+
+// extractKey extracts the API key from the key input, the header or the query parameter
+func (a *Activity) extractKey(input *Input) string {
+	if input.Key != "" {
+		return input.Key
+	}
+	for name, value := range input.Headers {
+		if strings.EqualFold(name, a.header) && value != "" {
+			return value
+		}
+	}
+	if a.query != "" {
+		return input.QueryParams[a.query]
+	}
+	return ""
+}
+
+// allowed checks if a route matches one of the allowed route patterns
+func allowed(routes []string, route string) bool {
+	for _, pattern := range routes {
+		if pattern == route || pattern == "*" {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/**") && strings.HasPrefix(route+"/", strings.TrimSuffix(pattern, "**")) {
+			return true
+		}
+		if matched, err := path.Match(pattern, route); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
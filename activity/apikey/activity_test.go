@@ -0,0 +1,277 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func writeKeys(t *testing.T, file string, keys ...Key) {
+	data, err := json.Marshal(KeyFile{Keys: keys})
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(file, data, 0600)
+	assert.Nil(t, err)
+}
+
+func validate(t *testing.T, act activity.Activity, values map[string]interface{}) map[string]interface{} {
+	ctx := newActivityContext(values)
+	_, err := act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func TestAPIKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apikey")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "keys.json")
+	writeKeys(t, file,
+		Key{
+			Hash:     "sha256:" + HashKey("gold-key"),
+			Owner:    "partner-a",
+			Plan:     "gold",
+			Metadata: map[string]interface{}{"tenant": "acme"},
+		},
+		Key{
+			Hash:   HashKey("pets-key"),
+			Owner:  "partner-b",
+			Plan:   "silver",
+			Routes: []string{"/pets/*", "/stores/**"},
+		},
+		Key{
+			Hash:    HashKey("expired-key"),
+			Owner:   "partner-c",
+			Expires: time.Now().Add(-time.Hour),
+		},
+		Key{
+			Hash:    HashKey("revoked-key"),
+			Owner:   "partner-d",
+			Revoked: true,
+		},
+	)
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"query":    "api_key",
+		"storeUrl": file,
+	}))
+	assert.Nil(t, err)
+
+	output := validate(t, act, map[string]interface{}{
+		"headers": map[string]string{"x-api-key": "gold-key"},
+	})
+	assert.True(t, output["valid"].(bool))
+	assert.Equal(t, "partner-a", output["owner"])
+	assert.Equal(t, "gold", output["plan"])
+	assert.Equal(t, map[string]interface{}{"tenant": "acme"}, output["metadata"])
+
+	output = validate(t, act, map[string]interface{}{
+		"queryParams": map[string]string{"api_key": "pets-key"},
+		"route":       "/pets/1",
+	})
+	assert.True(t, output["valid"].(bool))
+	assert.Equal(t, "partner-b", output["owner"])
+
+	tests := []struct {
+		input   map[string]interface{}
+		message error
+	}{
+		{map[string]interface{}{"key": "pets-key", "route": "/stores"}, nil},
+		{map[string]interface{}{"key": "pets-key", "route": "/stores/1/orders"}, nil},
+		{map[string]interface{}{"key": "pets-key", "route": "/pets/1/owners"}, ErrorRouteNotAllowed},
+		{map[string]interface{}{"key": "pets-key", "route": "/users"}, ErrorRouteNotAllowed},
+		{map[string]interface{}{"key": "expired-key"}, ErrorKeyExpired},
+		{map[string]interface{}{"key": "revoked-key"}, ErrorKeyRevoked},
+		{map[string]interface{}{"key": "other-key"}, ErrorUnknownKey},
+		{map[string]interface{}{"headers": map[string]string{"Authorization": "gold-key"}}, ErrorKeyNotFound},
+	}
+	for _, test := range tests {
+		output = validate(t, act, test.input)
+		if test.message == nil {
+			assert.True(t, output["valid"].(bool), test.input)
+			continue
+		}
+		assert.False(t, output["valid"].(bool), test.input)
+		assert.False(t, output["error"].(bool), test.input)
+		assert.Equal(t, test.message.Error(), output["validationMessage"], test.input)
+		assert.Equal(t, "", output["owner"], "the owner of an invalid key should not be set")
+		assert.Equal(t, "", output["plan"], test.input)
+		assert.Nil(t, output["metadata"], test.input)
+	}
+
+	writeKeys(t, file, Key{
+		Hash:    HashKey("gold-key"),
+		Owner:   "partner-a",
+		Revoked: true,
+	})
+	later := time.Now().Add(time.Minute)
+	err = os.Chtimes(file, later, later)
+	assert.Nil(t, err)
+	act.(*Activity).store.(*FileStore).Reload()
+	output = validate(t, act, map[string]interface{}{
+		"key": "gold-key",
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorKeyRevoked.Error(), output["validationMessage"])
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"storeUrl": filepath.Join(dir, "missing.json"),
+	}))
+	assert.NotNil(t, err)
+}
+
+type testStore struct {
+	keys map[string]*Key
+	err  error
+}
+
+func (s *testStore) Lookup(hash string) (*Key, error) {
+	return s.keys[hash], s.err
+}
+
+func TestAPIKeyStore(t *testing.T) {
+	store := &testStore{
+		keys: map[string]*Key{
+			HashKey("key"): {Owner: "partner"},
+		},
+	}
+	RegisterStore("test", func(url string) (Store, error) {
+		assert.Equal(t, "test://keys", url)
+		return store, nil
+	})
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"header":   "X-Partner-Key",
+		"store":    "test",
+		"storeUrl": "test://keys",
+	}))
+	assert.Nil(t, err)
+
+	output := validate(t, act, map[string]interface{}{
+		"headers": map[string]string{"X-Partner-Key": "key"},
+	})
+	assert.True(t, output["valid"].(bool))
+	assert.Equal(t, "partner", output["owner"])
+
+	store.err = errors.New("store unavailable")
+	output = validate(t, act, map[string]interface{}{
+		"headers": map[string]string{"X-Partner-Key": "key"},
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.True(t, output["error"].(bool))
+	assert.Equal(t, "store unavailable", output["errorMessage"])
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"store": "unknown",
+	}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,120 @@
+package apikey
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the API key validator
+type Settings struct {
+	Header   string `md:"header"`
+	Query    string `md:"query"`
+	Store    string `md:"store"`
+	StoreURL string `md:"storeUrl"`
+}
+
+// Input is the input for the API key validator
+type Input struct {
+	Key         string            `md:"key"`
+	Headers     map[string]string `md:"headers"`
+	QueryParams map[string]string `md:"queryParams"`
+	Route       string            `md:"route"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	key, err := coerce.ToString(values["key"])
+	if err != nil {
+		return err
+	}
+	r.Key = key
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	queryParams, err := coerce.ToParams(values["queryParams"])
+	if err != nil {
+		return err
+	}
+	r.QueryParams = queryParams
+	route, err := coerce.ToString(values["route"])
+	if err != nil {
+		return err
+	}
+	r.Route = route
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"key":         r.Key,
+		"headers":     r.Headers,
+		"queryParams": r.QueryParams,
+		"route":       r.Route,
+	}
+}
+
+// Output is the output of the API key validator
+type Output struct {
+	Valid             bool                   `md:"valid"`
+	Owner             string                 `md:"owner"`
+	Plan              string                 `md:"plan"`
+	Metadata          map[string]interface{} `md:"metadata"`
+	ValidationMessage string                 `md:"validationMessage"`
+	Error             bool                   `md:"error"`
+	ErrorMessage      string                 `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	valid, err := coerce.ToBool(values["valid"])
+	if err != nil {
+		return err
+	}
+	o.Valid = valid
+	owner, err := coerce.ToString(values["owner"])
+	if err != nil {
+		return err
+	}
+	o.Owner = owner
+	plan, err := coerce.ToString(values["plan"])
+	if err != nil {
+		return err
+	}
+	o.Plan = plan
+	metadata, err := coerce.ToObject(values["metadata"])
+	if err != nil {
+		return err
+	}
+	o.Metadata = metadata
+	validationMessage, err := coerce.ToString(values["validationMessage"])
+	if err != nil {
+		return err
+	}
+	o.ValidationMessage = validationMessage
+	e, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = e
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"valid":             o.Valid,
+		"owner":             o.Owner,
+		"plan":              o.Plan,
+		"metadata":          o.Metadata,
+		"validationMessage": o.ValidationMessage,
+		"error":             o.Error,
+		"errorMessage":      o.ErrorMessage,
+	}
+}
@@ -0,0 +1,126 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-flogo/microgateway/internal/watch"
+)
+
+const (
+	// StoreFile loads the keys from a JSON file
+	StoreFile = "file"
+)
+
+// Key is an API key, identified by the SHA-256 hash of the key
+type Key struct {
+	Hash     string                 `json:"hash"`
+	Owner    string                 `json:"owner"`
+	Plan     string                 `json:"plan"`
+	Routes   []string               `json:"routes"`
+	Metadata map[string]interface{} `json:"metadata"`
+	Expires  time.Time              `json:"expires"`
+	Revoked  bool                   `json:"revoked"`
+}
+
+// Store holds the API keys
+type Store interface {
+	// Lookup returns the key with the given hash, or nil if there is no such key
+	Lookup(hash string) (*Key, error)
+}
+
+// StoreFactory creates a store from the store URL setting
+type StoreFactory func(url string) (Store, error)
+
+var (
+	storesLock sync.RWMutex
+	stores     = map[string]StoreFactory{
+		StoreFile: func(url string) (Store, error) {
+			return NewFileStore(url)
+		},
+	}
+)
+
+// RegisterStore registers a store factory, allowing keys to be kept in other systems
+func RegisterStore(name string, factory StoreFactory) {
+	storesLock.Lock()
+	defer storesLock.Unlock()
+	stores[name] = factory
+}
+
+// NewStore creates a new store of the given kind
+func NewStore(kind, url string) (Store, error) {
+	if kind == "" {
+		kind = StoreFile
+	}
+	storesLock.RLock()
+	factory, ok := stores[kind]
+	storesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown store: %s", kind)
+	}
+	return factory(url)
+}
+
+// HashKey returns the hex encoded SHA-256 hash of a key
+func HashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// normalizeHash removes the optional algorithm prefix of a hash
+func normalizeHash(hash string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(hash), "sha256:"))
+}
+
+// KeyFile is the format of a key file
+type KeyFile struct {
+	Keys []Key `json:"keys"`
+}
+
+// FileStore is a store backed by a JSON file, which is reloaded when it changes
+type FileStore struct {
+	*watch.File
+}
+
+// NewFileStore creates a new file store and loads the keys
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("storeUrl is required for the file store")
+	}
+	file, err := watch.NewFile(strings.TrimPrefix(path, "file://"), "key file", parseKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{File: file}, nil
+}
+
+// parseKeyFile parses a key file into the keys by hash
+func parseKeyFile(data []byte) (interface{}, error) {
+	file := KeyFile{}
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*Key, len(file.Keys))
+	for i := range file.Keys {
+		key := &file.Keys[i]
+		key.Hash = normalizeHash(key.Hash)
+		if len(key.Hash) != 2*sha256.Size {
+			return nil, fmt.Errorf("invalid hash for key %d", i)
+		}
+		keys[key.Hash] = key
+	}
+	return keys, nil
+}
+
+// Lookup returns the key with the given hash, reloading the file when it has changed
+func (f *FileStore) Lookup(hash string) (*Key, error) {
+	keys := f.Value().(map[string]*Key)
+	return keys[normalizeHash(hash)], nil
+}
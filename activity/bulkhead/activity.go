@@ -0,0 +1,227 @@
+package bulkhead
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+	"github.com/project-flogo/microgateway/api"
+)
+
+const (
+	// OperationAcquire acquires a slot of the bulkhead
+	OperationAcquire = "acquire"
+	// OperationRelease releases a slot of the bulkhead
+	OperationRelease = "release"
+	// DefaultName is the name of the bulkhead when no name is given
+	DefaultName = "default"
+)
+
+var (
+	// ErrorQueueFull happens when the bulkhead and its queue are full
+	ErrorQueueFull = errors.New("bulkhead queue is full")
+	// ErrorQueueTimeout happens when a request waited too long in the queue
+	ErrorQueueTimeout = errors.New("bulkhead queue timeout")
+	activityMetadata  = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// Semaphore limits the number of concurrent requests
+type Semaphore struct {
+	slots    chan struct{}
+	maxQueue int
+	timeout  time.Duration
+	sync.Mutex
+	queued int
+}
+
+// NewSemaphore creates a new semaphore
+func NewSemaphore(maxConcurrency, maxQueue int, timeout time.Duration) *Semaphore {
+	return &Semaphore{
+		slots:    make(chan struct{}, maxConcurrency),
+		maxQueue: maxQueue,
+		timeout:  timeout,
+	}
+}
+
+// Acquire acquires a slot, waiting in the queue if all of the slots are in use
+func (s *Semaphore) Acquire() error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	s.Lock()
+	if s.queued >= s.maxQueue {
+		s.Unlock()
+		return ErrorQueueFull
+	}
+	s.queued++
+	s.Unlock()
+	defer func() {
+		s.Lock()
+		s.queued--
+		s.Unlock()
+	}()
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrorQueueTimeout
+	}
+}
+
+// Release releases a slot
+func (s *Semaphore) Release() {
+	select {
+	case <-s.slots:
+	default:
+	}
+}
+
+// Active is the number of slots in use
+func (s *Semaphore) Active() int {
+	return len(s.slots)
+}
+
+// Queued is the number of requests waiting for a slot
+func (s *Semaphore) Queued() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.queued
+}
+
+// permit identifies the slots held by a microgateway execution
+type permit struct {
+	host activity.Host
+	name string
+}
+
+// Activity is a bulkhead which limits the number of concurrent requests to a service
+type Activity struct {
+	maxConcurrency, maxQueue int
+	timeout                  time.Duration
+
+	sync.Mutex
+	semaphores map[string]*Semaphore
+	permits    map[permit]int
+}
+
+// New creates a new bulkhead
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{
+		MaxConcurrency: 10,
+		QueueTimeout:   1000,
+	}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	if settings.MaxConcurrency <= 0 {
+		return nil, errors.New("maxConcurrency should be greater than 0")
+	}
+	if settings.MaxQueue < 0 {
+		return nil, errors.New("maxQueue should not be negative")
+	}
+
+	act := &Activity{
+		maxConcurrency: settings.MaxConcurrency,
+		maxQueue:       settings.MaxQueue,
+		timeout:        time.Duration(settings.QueueTimeout) * time.Millisecond,
+		semaphores:     make(map[string]*Semaphore, 8),
+		permits:        make(map[permit]int, 256),
+	}
+
+	return act, nil
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	name := input.Name
+	if name == "" {
+		name = DefaultName
+	}
+	a.Lock()
+	semaphore := a.semaphores[name]
+	if semaphore == nil {
+		semaphore = NewSemaphore(a.maxConcurrency, a.maxQueue, a.timeout)
+		a.semaphores[name] = semaphore
+	}
+	a.Unlock()
+
+	host, output := ctx.ActivityHost(), Output{}
+	var rejected error
+	switch input.Operation {
+	case OperationRelease:
+		a.release(semaphore, permit{host: host, name: name})
+	default:
+		rejected = semaphore.Acquire()
+		if rejected != nil {
+			output.Rejected = true
+			output.RejectMessage = rejected.Error()
+			break
+		}
+		key := permit{host: host, name: name}
+		a.Lock()
+		a.permits[key]++
+		a.Unlock()
+		// release the slot when the execution ends without releasing it
+		if deferrer, ok := host.(api.Deferrer); ok {
+			deferrer.Defer(func() {
+				a.release(semaphore, key)
+			})
+		}
+	}
+
+	output.Active = semaphore.Active()
+	output.Queued = semaphore.Queued()
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+
+	if rejected != nil {
+		return true, rejected
+	}
+
+	return true, nil
+}
+
+// release releases a slot held by an execution
+func (a *Activity) release(semaphore *Semaphore, key permit) {
+	a.Lock()
+	held := a.permits[key]
+	if held <= 1 {
+		delete(a.permits, key)
+	} else {
+		a.permits[key] = held - 1
+	}
+	a.Unlock()
+	if held > 0 {
+		semaphore.Release()
+	}
+}
@@ -0,0 +1,212 @@
+package bulkhead
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input    map[string]interface{}
+	output   map[string]interface{}
+	deferred []func()
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func (a *activityContext) Defer(f func()) {
+	a.deferred = append(a.deferred, f)
+}
+
+func (a *activityContext) runDeferred() {
+	for _, f := range a.deferred {
+		f()
+	}
+	a.deferred = nil
+}
+
+func TestBulkhead(t *testing.T) {
+	activity, err := New(newInitContext(map[string]interface{}{
+		"maxConcurrency": 2,
+		"maxQueue":       1,
+		"queueTimeout":   50,
+	}))
+	assert.Nil(t, err)
+
+	a, b := newActivityContext(nil), newActivityContext(nil)
+	_, err = activity.Eval(a)
+	assert.Nil(t, err)
+	assert.False(t, a.output["rejected"].(bool), "request should not be rejected")
+	assert.Equal(t, 1, a.output["active"])
+	_, err = activity.Eval(b)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, b.output["active"])
+
+	c := newActivityContext(nil)
+	_, err = activity.Eval(c)
+	assert.Equal(t, ErrorQueueTimeout, err)
+	assert.True(t, c.output["rejected"].(bool), "request should be rejected")
+	assert.Equal(t, ErrorQueueTimeout.Error(), c.output["rejectMessage"])
+
+	waiting := sync.WaitGroup{}
+	waiting.Add(1)
+	queued := newActivityContext(map[string]interface{}{"operation": "acquire"})
+	go func() {
+		defer waiting.Done()
+		_, err := activity.Eval(queued)
+		assert.Nil(t, err)
+	}()
+	for activity.(*Activity).semaphores[DefaultName].Queued() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	d := newActivityContext(nil)
+	_, err = activity.Eval(d)
+	assert.Equal(t, ErrorQueueFull, err)
+	assert.Equal(t, 1, d.output["queued"])
+
+	a.input = map[string]interface{}{"operation": "release"}
+	_, err = activity.Eval(a)
+	assert.Nil(t, err)
+	waiting.Wait()
+	assert.False(t, queued.output["rejected"].(bool), "request should not be rejected")
+	assert.Equal(t, 2, queued.output["active"])
+
+	// the deferred release is a no-op after an explicit release
+	a.runDeferred()
+	assert.Equal(t, 2, activity.(*Activity).semaphores[DefaultName].Active())
+
+	// a failed execution releases its slot when it ends
+	b.runDeferred()
+	assert.Equal(t, 1, activity.(*Activity).semaphores[DefaultName].Active())
+	queued.runDeferred()
+	assert.Equal(t, 0, activity.(*Activity).semaphores[DefaultName].Active())
+	assert.Empty(t, activity.(*Activity).permits)
+
+	// releasing without acquiring does nothing
+	_, err = activity.Eval(newActivityContext(map[string]interface{}{"operation": "release"}))
+	assert.Nil(t, err)
+}
+
+func TestBulkheadNames(t *testing.T) {
+	activity, err := New(newInitContext(map[string]interface{}{
+		"maxConcurrency": 1,
+	}))
+	assert.Nil(t, err)
+
+	a := newActivityContext(map[string]interface{}{"name": "a"})
+	_, err = activity.Eval(a)
+	assert.Nil(t, err)
+	_, err = activity.Eval(newActivityContext(map[string]interface{}{"name": "a"}))
+	assert.Equal(t, ErrorQueueFull, err)
+	_, err = activity.Eval(newActivityContext(map[string]interface{}{"name": "b"}))
+	assert.Nil(t, err)
+
+	a.runDeferred()
+	_, err = activity.Eval(newActivityContext(map[string]interface{}{"name": "a"}))
+	assert.Nil(t, err)
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"maxConcurrency": 0,
+	}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,84 @@
+package bulkhead
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the bulkhead
+type Settings struct {
+	MaxConcurrency int `md:"maxConcurrency"`
+	MaxQueue       int `md:"maxQueue"`
+	QueueTimeout   int `md:"queueTimeout"`
+}
+
+// Input is the input for the bulkhead
+type Input struct {
+	Name      string `md:"name"`
+	Operation string `md:"operation,allowed(acquire,release)"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	name, err := coerce.ToString(values["name"])
+	if err != nil {
+		return err
+	}
+	r.Name = name
+	operation, err := coerce.ToString(values["operation"])
+	if err != nil {
+		return err
+	}
+	r.Operation = operation
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"name":      r.Name,
+		"operation": r.Operation,
+	}
+}
+
+// Output is the output of the bulkhead
+type Output struct {
+	Rejected      bool   `md:"rejected"`
+	RejectMessage string `md:"rejectMessage"`
+	Active        int    `md:"active"`
+	Queued        int    `md:"queued"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	rejected, err := coerce.ToBool(values["rejected"])
+	if err != nil {
+		return err
+	}
+	o.Rejected = rejected
+	rejectMessage, err := coerce.ToString(values["rejectMessage"])
+	if err != nil {
+		return err
+	}
+	o.RejectMessage = rejectMessage
+	active, err := coerce.ToInt(values["active"])
+	if err != nil {
+		return err
+	}
+	o.Active = active
+	queued, err := coerce.ToInt(values["queued"])
+	if err != nil {
+		return err
+	}
+	o.Queued = queued
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"rejected":      o.Rejected,
+		"rejectMessage": o.RejectMessage,
+		"active":        o.Active,
+		"queued":        o.Queued,
+	}
+}
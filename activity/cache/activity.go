@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+const (
+	// OperationLookup looks up a cached response
+	OperationLookup = "lookup"
+	// OperationStore stores a response in the cache
+	OperationStore = "store"
+	// DefaultTTL is the default number of seconds a response is fresh
+	DefaultTTL = 60
+	// RevalidateTimeout is how long a request has for refreshing a stale response
+	// before another request is asked to refresh it
+	RevalidateTimeout = 30 * time.Second
+)
+
+var (
+	// ErrorKeyRequired happens when the cache key is empty
+	ErrorKeyRequired = errors.New("cache key is required")
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+	// Now returns the current time
+	Now = time.Now
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new cache
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{
+		TTL: DefaultTTL,
+	}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	if settings.TTL < 0 || settings.StaleWhileRevalidate < 0 || settings.StaleIfError < 0 {
+		return nil, errors.New("ttl, staleWhileRevalidate and staleIfError can't be negative")
+	}
+	store, err := NewStore(&settings)
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{
+		ttl:                  time.Duration(settings.TTL) * time.Second,
+		staleWhileRevalidate: time.Duration(settings.StaleWhileRevalidate) * time.Second,
+		staleIfError:         time.Duration(settings.StaleIfError) * time.Second,
+		ignoreCacheControl:   settings.IgnoreCacheControl,
+		store:                store,
+	}
+	return act, nil
+}
+
+// Activity is a response cache
+type Activity struct {
+	ttl, staleWhileRevalidate, staleIfError time.Duration
+	ignoreCacheControl                      bool
+	store                                   Store
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := Output{}
+	key, err := Key(input.Key)
+	if err == nil {
+		switch input.Operation {
+		case "", OperationLookup:
+			err = a.lookup(key, &output)
+		case OperationStore:
+			err = a.save(key, &input, &output)
+		default:
+			err = fmt.Errorf("unknown operation: %s", input.Operation)
+		}
+	}
+	if err != nil {
+		output = Output{
+			Revalidate:   input.Operation != OperationStore,
+			Error:        true,
+			ErrorMessage: err.Error(),
+		}
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Key returns the cache key for a value. The value is typically a string or an object
+// built from the parts of the request that select the response
+func Key(value interface{}) (string, error) {
+	if value == nil || value == "" {
+		return "", ErrorKeyRequired
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid cache key: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// lookup looks up the response for key. A stale response is a hit while it can be served
+// during revalidation, the first request seeing it is asked to revalidate it
+func (a *Activity) lookup(key string, output *Output) error {
+	entry, err := a.store.Get(key)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		output.Revalidate = true
+		return nil
+	}
+
+	now := Now()
+	output.Code, output.Data, output.Headers = entry.Code, entry.Data, entry.Headers
+	output.Age = int64(now.Sub(entry.Stored) / time.Second)
+	switch {
+	case now.Before(entry.Expires):
+		output.Hit = true
+	case now.Before(entry.StaleWhileRevalidate):
+		output.Hit, output.Stale = true, true
+		output.Revalidate, err = a.store.Lock(key, RevalidateTimeout)
+		if err != nil {
+			return err
+		}
+	default:
+		output.Stale, output.Revalidate = true, true
+	}
+	return nil
+}
+
+// save stores a successful response for key unless the Cache-Control header forbids it
+func (a *Activity) save(key string, input *Input, output *Output) error {
+	if input.Code != 0 && (input.Code < 200 || input.Code > 299) {
+		return nil
+	}
+
+	ttl, staleWhileRevalidate, staleIfError := a.ttl, a.staleWhileRevalidate, a.staleIfError
+	if !a.ignoreCacheControl {
+		for name, value := range input.Headers {
+			if !strings.EqualFold(name, "Cache-Control") {
+				continue
+			}
+			control := ParseCacheControl(value)
+			if !control.Cacheable() {
+				return nil
+			}
+			if seconds := control.TTL(); seconds >= 0 {
+				ttl = time.Duration(seconds) * time.Second
+			}
+			if control.StaleWhileRevalidate >= 0 {
+				staleWhileRevalidate = time.Duration(control.StaleWhileRevalidate) * time.Second
+			}
+			if control.StaleIfError >= 0 {
+				staleIfError = time.Duration(control.StaleIfError) * time.Second
+			}
+		}
+	}
+
+	now := Now()
+	entry := &Entry{
+		Code:    input.Code,
+		Data:    input.Data,
+		Headers: input.Headers,
+		Stored:  now,
+		Expires: now.Add(ttl),
+	}
+	if entry.Code == 0 {
+		entry.Code = 200
+	}
+	entry.StaleWhileRevalidate = entry.Expires.Add(staleWhileRevalidate)
+	entry.StaleIfError = entry.Expires.Add(staleIfError)
+	if !entry.Retain().After(now) {
+		return nil
+	}
+	err := a.store.Set(key, entry)
+	if err != nil {
+		return err
+	}
+	output.Stored = true
+	return nil
+}
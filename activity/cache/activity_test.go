@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	test := func(settings map[string]interface{}) {
+		activity, err := New(newInitContext(settings))
+		assert.Nil(t, err)
+
+		eval := func(input map[string]interface{}) map[string]interface{} {
+			ctx := newActivityContext(input)
+			_, err := activity.Eval(ctx)
+			assert.Nil(t, err)
+			return ctx.output
+		}
+		key := map[string]interface{}{
+			"method": "GET",
+			"path":   "/pets/1",
+			"tenant": "acme",
+		}
+
+		output := eval(map[string]interface{}{"key": key})
+		assert.False(t, output["hit"].(bool), "should be a miss")
+		assert.True(t, output["revalidate"].(bool), "a miss should be revalidated")
+
+		output = eval(map[string]interface{}{
+			"operation": "store",
+			"key":       key,
+			"code":      200,
+			"data":      map[string]interface{}{"name": "sally"},
+			"headers":   map[string]string{"Content-Type": "application/json"},
+		})
+		assert.True(t, output["stored"].(bool), "response should be stored")
+
+		output = eval(map[string]interface{}{"key": map[string]interface{}{
+			"tenant": "acme",
+			"path":   "/pets/1",
+			"method": "GET",
+		}})
+		assert.True(t, output["hit"].(bool), "should be a hit")
+		assert.False(t, output["stale"].(bool), "should be fresh")
+		assert.False(t, output["revalidate"].(bool), "should not be revalidated")
+		assert.Equal(t, 200, output["code"])
+		assert.Equal(t, "sally", output["data"].(map[string]interface{})["name"])
+		assert.Equal(t, "application/json", output["headers"].(map[string]string)["Content-Type"])
+
+		key["tenant"] = "other"
+		output = eval(map[string]interface{}{"key": key})
+		assert.False(t, output["hit"].(bool), "other tenant should be a miss")
+
+		output = eval(map[string]interface{}{"operation": "store", "key": "/pets/2", "code": 500})
+		assert.False(t, output["stored"].(bool), "errors should not be stored")
+		output = eval(map[string]interface{}{
+			"operation": "store",
+			"key":       "/pets/2",
+			"headers":   map[string]string{"cache-control": "private, max-age=60"},
+		})
+		assert.False(t, output["stored"].(bool), "private responses should not be stored")
+		output = eval(map[string]interface{}{
+			"operation": "store",
+			"key":       "/pets/2",
+			"headers":   map[string]string{"Cache-Control": "max-age=0"},
+		})
+		assert.False(t, output["stored"].(bool), "expired responses should not be stored")
+		output = eval(map[string]interface{}{"key": "/pets/2"})
+		assert.False(t, output["hit"].(bool), "should be a miss")
+
+		output = eval(map[string]interface{}{"key": ""})
+		assert.True(t, output["error"].(bool), "empty key should be an error")
+		assert.Equal(t, ErrorKeyRequired.Error(), output["errorMessage"])
+	}
+
+	test(map[string]interface{}{})
+
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer server.Close()
+	test(map[string]interface{}{
+		"store":    "redis",
+		"storeUrl": "redis://" + server.Addr(),
+	})
+	assert.True(t, len(server.Keys()) > 0, "responses should be in the shared store")
+	for _, key := range server.Keys() {
+		assert.Contains(t, key, DefaultStorePrefix+":")
+	}
+}
+
+func TestCacheStale(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	Now = func() time.Time {
+		return now
+	}
+	defer func() {
+		Now = time.Now
+	}()
+
+	activity, err := New(newInitContext(map[string]interface{}{
+		"ttl":                  10,
+		"staleWhileRevalidate": 20,
+	}))
+	assert.Nil(t, err)
+	eval := func(input map[string]interface{}) map[string]interface{} {
+		ctx := newActivityContext(input)
+		_, err := activity.Eval(ctx)
+		assert.Nil(t, err)
+		return ctx.output
+	}
+
+	output := eval(map[string]interface{}{"operation": "store", "key": "pets", "data": "a"})
+	assert.True(t, output["stored"].(bool), "response should be stored")
+
+	now = now.Add(15 * time.Second)
+	output = eval(map[string]interface{}{"key": "pets"})
+	assert.True(t, output["hit"].(bool), "stale response should be served while revalidating")
+	assert.True(t, output["stale"].(bool), "response should be stale")
+	assert.True(t, output["revalidate"].(bool), "first request should revalidate")
+	assert.Equal(t, int64(15), output["age"])
+	output = eval(map[string]interface{}{"key": "pets"})
+	assert.True(t, output["hit"].(bool), "stale response should be served while revalidating")
+	assert.False(t, output["revalidate"].(bool), "only one request should revalidate")
+
+	now = now.Add(RevalidateTimeout - 10*time.Second)
+	output = eval(map[string]interface{}{"key": "pets"})
+	assert.True(t, output["revalidate"].(bool), "revalidation should be retried after timeout")
+	output = eval(map[string]interface{}{"operation": "store", "key": "pets", "data": "b"})
+	assert.True(t, output["stored"].(bool), "response should be stored")
+	output = eval(map[string]interface{}{"key": "pets"})
+	assert.True(t, output["hit"].(bool), "should be a hit")
+	assert.False(t, output["stale"].(bool), "should be fresh")
+	assert.Equal(t, "b", output["data"])
+
+	output = eval(map[string]interface{}{
+		"operation": "store",
+		"key":       "owners",
+		"data":      "c",
+		"headers":   map[string]string{"Cache-Control": "max-age=5, stale-while-revalidate=0, stale-if-error=60"},
+	})
+	assert.True(t, output["stored"].(bool), "response should be stored")
+	now = now.Add(30 * time.Second)
+	output = eval(map[string]interface{}{"key": "owners"})
+	assert.False(t, output["hit"].(bool), "stale response should not be a hit")
+	assert.True(t, output["stale"].(bool), "response should be stale")
+	assert.True(t, output["revalidate"].(bool), "response should be revalidated")
+	assert.Equal(t, "c", output["data"], "stale response should be available if the backend fails")
+	now = now.Add(time.Minute)
+	output = eval(map[string]interface{}{"key": "owners"})
+	assert.False(t, output["stale"].(bool), "response should be gone")
+	assert.Nil(t, output["data"])
+}
+
+func TestMemoryStore(t *testing.T) {
+	entry := func(data string) *Entry {
+		return &Entry{Data: data, Expires: Now().Add(time.Minute)}
+	}
+
+	store := NewMemoryStore(2, 0)
+	assert.Nil(t, store.Set("a", entry("a")))
+	assert.Nil(t, store.Set("b", entry("b")))
+	found, err := store.Get("a")
+	assert.Nil(t, err)
+	assert.NotNil(t, found)
+	assert.Nil(t, store.Set("c", entry("c")))
+	assert.Equal(t, 2, store.Len())
+	found, err = store.Get("b")
+	assert.Nil(t, err)
+	assert.Nil(t, found, "least recently used entry should be evicted")
+	found, err = store.Get("a")
+	assert.Nil(t, err)
+	assert.NotNil(t, found)
+
+	store = NewMemoryStore(100, 200)
+	assert.NotNil(t, store.Set("big", entry(string(make([]byte, 200)))), "entry should be too big")
+	for _, key := range []string{"a", "b", "c", "d"} {
+		assert.Nil(t, store.Set(key, entry(key)))
+	}
+	assert.True(t, store.Len() < 4, "entries should be evicted beyond the size limit")
+	found, err = store.Get("d")
+	assert.Nil(t, err)
+	assert.NotNil(t, found)
+
+	locked, err := store.Lock("a", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	locked, err = store.Lock("a", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, locked)
+	assert.Nil(t, store.Set("a", entry("a")))
+	locked, err = store.Lock("a", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, locked, "setting the entry should release the lock")
+}
+
+func TestParseCacheControl(t *testing.T) {
+	control := ParseCacheControl(`public, max-age=60, s-maxage="120", stale-if-error=600`)
+	assert.True(t, control.Cacheable())
+	assert.Equal(t, 120, control.TTL())
+	assert.Equal(t, -1, control.StaleWhileRevalidate)
+	assert.Equal(t, 600, control.StaleIfError)
+
+	control = ParseCacheControl("No-Store")
+	assert.False(t, control.Cacheable())
+	assert.Equal(t, -1, control.TTL())
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CacheControl are the directives of a Cache-Control response header relevant to a shared cache.
+// The durations are in seconds, -1 when the directive is absent
+type CacheControl struct {
+	NoStore              bool
+	NoCache              bool
+	Private              bool
+	MaxAge               int
+	SharedMaxAge         int
+	StaleWhileRevalidate int
+	StaleIfError         int
+}
+
+// ParseCacheControl parses the value of a Cache-Control header
+func ParseCacheControl(value string) CacheControl {
+	control := CacheControl{
+		MaxAge:               -1,
+		SharedMaxAge:         -1,
+		StaleWhileRevalidate: -1,
+		StaleIfError:         -1,
+	}
+	for _, directive := range strings.Split(value, ",") {
+		name, argument := strings.TrimSpace(directive), ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, argument = strings.TrimSpace(name[:i]), strings.Trim(strings.TrimSpace(name[i+1:]), `"`)
+		}
+		seconds := func() int {
+			value, err := strconv.Atoi(argument)
+			if err != nil || value < 0 {
+				return 0
+			}
+			return value
+		}
+		switch strings.ToLower(name) {
+		case "no-store":
+			control.NoStore = true
+		case "no-cache":
+			control.NoCache = true
+		case "private":
+			control.Private = true
+		case "max-age":
+			control.MaxAge = seconds()
+		case "s-maxage":
+			control.SharedMaxAge = seconds()
+		case "stale-while-revalidate":
+			control.StaleWhileRevalidate = seconds()
+		case "stale-if-error":
+			control.StaleIfError = seconds()
+		}
+	}
+	return control
+}
+
+// Cacheable is true if a shared cache may store the response
+func (c CacheControl) Cacheable() bool {
+	return !c.NoStore && !c.NoCache && !c.Private
+}
+
+// TTL returns the number of seconds the response is fresh, or -1 if it isn't given
+func (c CacheControl) TTL() int {
+	if c.SharedMaxAge >= 0 {
+		return c.SharedMaxAge
+	}
+	return c.MaxAge
+}
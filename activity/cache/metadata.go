@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the cache
+type Settings struct {
+	TTL                  int    `md:"ttl"`
+	StaleWhileRevalidate int    `md:"staleWhileRevalidate"`
+	StaleIfError         int    `md:"staleIfError"`
+	IgnoreCacheControl   bool   `md:"ignoreCacheControl"`
+	MaxEntries           int    `md:"maxEntries"`
+	MaxBytes             int    `md:"maxBytes"`
+	Store                string `md:"store"`
+	StoreURL             string `md:"storeUrl"`
+	StorePrefix          string `md:"storePrefix"`
+}
+
+// Input is the input for the cache
+type Input struct {
+	Operation string            `md:"operation,allowed(lookup,store)"`
+	Key       interface{}       `md:"key,required"`
+	Code      int               `md:"code"`
+	Data      interface{}       `md:"data"`
+	Headers   map[string]string `md:"headers"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	operation, err := coerce.ToString(values["operation"])
+	if err != nil {
+		return err
+	}
+	r.Operation = operation
+	r.Key = values["key"]
+	code, err := coerce.ToInt(values["code"])
+	if err != nil {
+		return err
+	}
+	r.Code = code
+	r.Data = values["data"]
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"operation": r.Operation,
+		"key":       r.Key,
+		"code":      r.Code,
+		"data":      r.Data,
+		"headers":   r.Headers,
+	}
+}
+
+// Output is the output of the cache
+type Output struct {
+	Hit          bool              `md:"hit"`
+	Stale        bool              `md:"stale"`
+	Revalidate   bool              `md:"revalidate"`
+	Stored       bool              `md:"stored"`
+	Code         int               `md:"code"`
+	Data         interface{}       `md:"data"`
+	Headers      map[string]string `md:"headers"`
+	Age          int64             `md:"age"`
+	Error        bool              `md:"error"`
+	ErrorMessage string            `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	hit, err := coerce.ToBool(values["hit"])
+	if err != nil {
+		return err
+	}
+	o.Hit = hit
+	stale, err := coerce.ToBool(values["stale"])
+	if err != nil {
+		return err
+	}
+	o.Stale = stale
+	revalidate, err := coerce.ToBool(values["revalidate"])
+	if err != nil {
+		return err
+	}
+	o.Revalidate = revalidate
+	stored, err := coerce.ToBool(values["stored"])
+	if err != nil {
+		return err
+	}
+	o.Stored = stored
+	code, err := coerce.ToInt(values["code"])
+	if err != nil {
+		return err
+	}
+	o.Code = code
+	o.Data = values["data"]
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.Headers = headers
+	age, err := coerce.ToInt64(values["age"])
+	if err != nil {
+		return err
+	}
+	o.Age = age
+	hasError, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = hasError
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"hit":          o.Hit,
+		"stale":        o.Stale,
+		"revalidate":   o.Revalidate,
+		"stored":       o.Stored,
+		"code":         o.Code,
+		"data":         o.Data,
+		"headers":      o.Headers,
+		"age":          o.Age,
+		"error":        o.Error,
+		"errorMessage": o.ErrorMessage,
+	}
+}
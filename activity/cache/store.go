@@ -0,0 +1,279 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	// StoreMemory keeps the cache in process memory
+	StoreMemory = "memory"
+	// StoreRedis keeps the cache in a Redis compatible server
+	StoreRedis = "redis"
+	// DefaultStorePrefix is the default prefix for keys in the store
+	DefaultStorePrefix = "flogo:cache"
+	// DefaultMaxEntries is the default maximum number of entries in the memory store
+	DefaultMaxEntries = 1024
+)
+
+// Entry is a cached response
+type Entry struct {
+	Code    int               `json:"code"`
+	Data    interface{}       `json:"data"`
+	Headers map[string]string `json:"headers"`
+	// Stored is when the response was stored
+	Stored time.Time `json:"stored"`
+	// Expires is when the response becomes stale
+	Expires time.Time `json:"expires"`
+	// StaleWhileRevalidate is until when the stale response can be served while it is refreshed
+	StaleWhileRevalidate time.Time `json:"staleWhileRevalidate"`
+	// StaleIfError is until when the stale response can be served if the backend fails
+	StaleIfError time.Time `json:"staleIfError"`
+}
+
+// Retain returns until when the entry has to be kept
+func (e *Entry) Retain() time.Time {
+	retain := e.Expires
+	if e.StaleWhileRevalidate.After(retain) {
+		retain = e.StaleWhileRevalidate
+	}
+	if e.StaleIfError.After(retain) {
+		retain = e.StaleIfError
+	}
+	return retain
+}
+
+// Store holds the cached responses, possibly shared between gateway replicas
+type Store interface {
+	// Get returns the entry for key, or nil if there is no such entry
+	Get(key string) (*Entry, error)
+	// Set stores the entry for key until the entry doesn't have to be retained anymore
+	Set(key string, entry *Entry) error
+	// Lock acquires the lock for refreshing the entry for key, the lock is released
+	// when the entry is set or after timeout
+	Lock(key string, timeout time.Duration) (bool, error)
+}
+
+// StoreFactory creates a store from the settings
+type StoreFactory func(settings *Settings) (Store, error)
+
+var (
+	storesLock sync.RWMutex
+	stores     = map[string]StoreFactory{
+		StoreMemory: func(settings *Settings) (Store, error) {
+			return NewMemoryStore(settings.MaxEntries, settings.MaxBytes), nil
+		},
+		StoreRedis: func(settings *Settings) (Store, error) {
+			if settings.StoreURL == "" {
+				return nil, errors.New("storeUrl is required for the redis store")
+			}
+			options, err := redis.ParseURL(settings.StoreURL)
+			if err != nil {
+				return nil, err
+			}
+			return NewRedisStore(redis.NewClient(options), settings.StorePrefix)
+		},
+	}
+)
+
+// RegisterStore registers a store factory, allowing responses to be cached in other systems
+func RegisterStore(name string, factory StoreFactory) {
+	storesLock.Lock()
+	defer storesLock.Unlock()
+	stores[name] = factory
+}
+
+// NewStore creates a new store of the kind given by the settings
+func NewStore(settings *Settings) (Store, error) {
+	kind := settings.Store
+	if kind == "" {
+		kind = StoreMemory
+	}
+	storesLock.RLock()
+	factory, ok := stores[kind]
+	storesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown store: %s", kind)
+	}
+	return factory(settings)
+}
+
+// MemoryStore is a least recently used cache local to this process
+type MemoryStore struct {
+	maxEntries, maxBytes int
+
+	mutex   sync.Mutex
+	entries *list.List
+	index   map[string]*list.Element
+	bytes   int
+	locks   map[string]time.Time
+}
+
+type memoryEntry struct {
+	key   string
+	entry *Entry
+	size  int
+}
+
+// NewMemoryStore creates a new memory store holding at most maxEntries entries
+// and, if maxBytes is positive, at most maxBytes bytes of serialized entries
+func NewMemoryStore(maxEntries, maxBytes int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    list.New(),
+		index:      make(map[string]*list.Element, 256),
+		locks:      make(map[string]time.Time, 8),
+	}
+}
+
+// Get returns the entry for key and marks it as recently used
+func (m *MemoryStore) Get(key string) (*Entry, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	element, ok := m.index[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if Now().After(entry.entry.Retain()) {
+		m.remove(element)
+		return nil, nil
+	}
+	m.entries.MoveToFront(element)
+	return entry.entry, nil
+}
+
+// Set stores the entry for key, evicting the least recently used entries beyond the limits
+func (m *MemoryStore) Set(key string, entry *Entry) error {
+	size := 0
+	if m.maxBytes > 0 {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		size = len(key) + len(data)
+		if size > m.maxBytes {
+			return fmt.Errorf("entry of %d bytes is larger than the cache", size)
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.locks, key)
+	if element, ok := m.index[key]; ok {
+		m.remove(element)
+	}
+	m.index[key] = m.entries.PushFront(&memoryEntry{
+		key:   key,
+		entry: entry,
+		size:  size,
+	})
+	m.bytes += size
+	for m.entries.Len() > m.maxEntries || (m.maxBytes > 0 && m.bytes > m.maxBytes) {
+		m.remove(m.entries.Back())
+	}
+	return nil
+}
+
+// Lock acquires the lock for refreshing the entry for key
+func (m *MemoryStore) Lock(key string, timeout time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := Now()
+	if expires, ok := m.locks[key]; ok && now.Before(expires) {
+		return false, nil
+	}
+	for k, expires := range m.locks {
+		if !now.Before(expires) {
+			delete(m.locks, k)
+		}
+	}
+	m.locks[key] = now.Add(timeout)
+	return true, nil
+}
+
+// Len returns the number of entries in the store
+func (m *MemoryStore) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.entries.Len()
+}
+
+func (m *MemoryStore) remove(element *list.Element) {
+	entry := m.entries.Remove(element).(*memoryEntry)
+	delete(m.index, entry.key)
+	m.bytes -= entry.size
+}
+
+// RedisStore is a store backed by a Redis compatible server
+type RedisStore struct {
+	prefix string
+	client *redis.Client
+}
+
+// NewRedisStore creates a new redis store
+func NewRedisStore(client *redis.Client, prefix string) (*RedisStore, error) {
+	if prefix == "" {
+		prefix = DefaultStorePrefix
+	}
+	err := client.Ping().Err()
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{
+		prefix: prefix,
+		client: client,
+	}, nil
+}
+
+// Get returns the entry for key
+func (r *RedisStore) Get(key string) (*Entry, error) {
+	data, err := r.client.Get(r.prefix + ":" + key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	entry := &Entry{}
+	err = json.Unmarshal(data, entry)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Set stores the entry for key, the entry expires when it doesn't have to be retained anymore
+func (r *RedisStore) Set(key string, entry *Entry) error {
+	ttl := entry.Retain().Sub(Now())
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(r.prefix+":"+key, data, ttl)
+		pipe.Del(r.prefix + ":lock:" + key)
+		return nil
+	})
+	return err
+}
+
+// Lock acquires the lock for refreshing the entry for key
+func (r *RedisStore) Lock(key string, timeout time.Duration) (bool, error) {
+	return r.client.SetNX(r.prefix+":lock:"+key, 1, timeout).Result()
+}
@@ -0,0 +1,262 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/coerce"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+// The CORS headers
+const (
+	HeaderOrigin           = "Origin"
+	HeaderVary             = "Vary"
+	HeaderRequestMethod    = "Access-Control-Request-Method"
+	HeaderRequestHeaders   = "Access-Control-Request-Headers"
+	HeaderAllowOrigin      = "Access-Control-Allow-Origin"
+	HeaderAllowMethods     = "Access-Control-Allow-Methods"
+	HeaderAllowHeaders     = "Access-Control-Allow-Headers"
+	HeaderAllowCredentials = "Access-Control-Allow-Credentials"
+	HeaderExposeHeaders    = "Access-Control-Expose-Headers"
+	HeaderMaxAge           = "Access-Control-Max-Age"
+	varyPreflight          = "Origin, Access-Control-Request-Method, Access-Control-Request-Headers"
+	wildcard               = "*"
+)
+
+var (
+	// DefaultAllowMethods are the methods allowed when no methods are configured
+	DefaultAllowMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	activityMetadata    = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new CORS policy
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{
+		allowCredentials: settings.AllowCredentials,
+		maxAge:           settings.MaxAge,
+	}
+	origins, err := toStrings(settings.AllowOrigins)
+	if err != nil {
+		return nil, err
+	}
+	for _, origin := range origins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == wildcard:
+			if act.allowCredentials {
+				return nil, fmt.Errorf("the %s origin can't be used with credentials", wildcard)
+			}
+			act.allowAllOrigins = true
+		case strings.Count(origin, wildcard) > 1:
+			return nil, fmt.Errorf("invalid origin: %s", origin)
+		case strings.Contains(origin, wildcard):
+			index := strings.Index(origin, wildcard)
+			act.wildcardOrigins = append(act.wildcardOrigins, [2]string{origin[:index], origin[index+1:]})
+		default:
+			act.allowOrigins = append(act.allowOrigins, origin)
+		}
+	}
+
+	methods, err := toStrings(settings.AllowMethods)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) == 0 {
+		methods = DefaultAllowMethods
+	}
+	for _, method := range methods {
+		act.allowMethods = append(act.allowMethods, strings.ToUpper(method))
+	}
+
+	headers, err := toStrings(settings.AllowHeaders)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range headers {
+		if header == wildcard {
+			act.allowAllHeaders = true
+			continue
+		}
+		act.allowHeaders = append(act.allowHeaders, http.CanonicalHeaderKey(header))
+	}
+
+	act.exposeHeaders, err = toStrings(settings.ExposeHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return act, nil
+}
+
+// Activity is a CORS policy
+type Activity struct {
+	allowAllOrigins  bool
+	allowOrigins     []string
+	wildcardOrigins  [][2]string
+	allowMethods     []string
+	allowAllHeaders  bool
+	allowHeaders     []string
+	exposeHeaders    []string
+	allowCredentials bool
+	maxAge           int
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := a.evaluate(&input)
+	if output.Preflight {
+		// Preflight requests are answered without executing the remaining steps
+		ctx.ActivityHost().Return(nil, nil)
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// evaluate evaluates the policy for a request
+func (a *Activity) evaluate(input *Input) Output {
+	output := Output{
+		Allowed: true,
+		Headers: make(map[string]string),
+	}
+	origin := header(input.Headers, HeaderOrigin)
+	requestMethod := header(input.Headers, HeaderRequestMethod)
+	output.Preflight = requestMethod != "" &&
+		(input.Method == "" || strings.EqualFold(input.Method, http.MethodOptions))
+	if !a.allowAllOrigins || a.allowCredentials {
+		output.Headers[HeaderVary] = HeaderOrigin
+	}
+	if output.Preflight {
+		output.Headers[HeaderVary] = varyPreflight
+	}
+	if origin == "" {
+		// Not a cross origin request
+		output.Preflight = false
+		return output
+	}
+
+	reject := func(format string, a ...interface{}) Output {
+		output.Allowed = false
+		output.RejectMessage = fmt.Sprintf(format, a...)
+		return output
+	}
+	if !a.originAllowed(origin) {
+		return reject("origin '%s' is not allowed", origin)
+	}
+
+	if output.Preflight {
+		method := strings.ToUpper(strings.TrimSpace(requestMethod))
+		if !contains(a.allowMethods, method) {
+			return reject("method '%s' is not allowed", method)
+		}
+		var headers []string
+		for _, name := range strings.Split(header(input.Headers, HeaderRequestHeaders), ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !a.allowAllHeaders && !contains(a.allowHeaders, name) {
+				return reject("header '%s' is not allowed", name)
+			}
+			headers = append(headers, name)
+		}
+		output.Headers[HeaderAllowMethods] = strings.Join(a.allowMethods, ", ")
+		if len(headers) > 0 {
+			output.Headers[HeaderAllowHeaders] = strings.Join(headers, ", ")
+		}
+		if a.maxAge > 0 {
+			output.Headers[HeaderMaxAge] = strconv.Itoa(a.maxAge)
+		}
+	} else if len(a.exposeHeaders) > 0 {
+		output.Headers[HeaderExposeHeaders] = strings.Join(a.exposeHeaders, ", ")
+	}
+
+	if a.allowAllOrigins && !a.allowCredentials {
+		output.Headers[HeaderAllowOrigin] = wildcard
+	} else {
+		output.Headers[HeaderAllowOrigin] = origin
+	}
+	if a.allowCredentials {
+		output.Headers[HeaderAllowCredentials] = "true"
+	}
+	return output
+}
+
+// originAllowed checks if an origin is allowed
+func (a *Activity) originAllowed(origin string) bool {
+	if a.allowAllOrigins {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if contains(a.allowOrigins, origin) {
+		return true
+	}
+	for _, pattern := range a.wildcardOrigins {
+		if len(origin) > len(pattern[0])+len(pattern[1]) &&
+			strings.HasPrefix(origin, pattern[0]) && strings.HasSuffix(origin, pattern[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// header returns the value of a header, matching the name case insensitively
+func header(headers map[string]string, name string) string {
+	if value, ok := headers[name]; ok {
+		return value
+	}
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+func toStrings(values []interface{}) ([]string, error) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		s, err := coerce.ToString(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
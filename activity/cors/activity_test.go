@@ -0,0 +1,232 @@
+package cors
+
+import (
+	"testing"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input    map[string]interface{}
+	output   map[string]interface{}
+	returned bool
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+	a.returned = true
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func evaluate(t *testing.T, act activity.Activity, method string, headers map[string]string) (map[string]interface{}, bool) {
+	ctx := newActivityContext(map[string]interface{}{
+		"method":  method,
+		"headers": headers,
+	})
+	_, err := act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output, ctx.returned
+}
+
+func TestCORS(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"allowOrigins":     []interface{}{"https://app.example.com", "https://*.example.org"},
+		"allowMethods":     []interface{}{"GET", "put"},
+		"allowHeaders":     []interface{}{"content-type", "X-Request-Id"},
+		"exposeHeaders":    []interface{}{"X-Request-Id"},
+		"allowCredentials": true,
+		"maxAge":           600,
+	}))
+	assert.Nil(t, err)
+
+	output, returned := evaluate(t, act, "OPTIONS", map[string]string{
+		"Origin":                         "https://app.example.com",
+		"Access-Control-Request-Method":  "PUT",
+		"Access-Control-Request-Headers": "content-type, x-request-id",
+	})
+	assert.True(t, returned, "preflight requests should be answered immediately")
+	assert.True(t, output["allowed"].(bool))
+	assert.True(t, output["preflight"].(bool))
+	assert.Equal(t, map[string]string{
+		HeaderAllowOrigin:      "https://app.example.com",
+		HeaderAllowMethods:     "GET, PUT",
+		HeaderAllowHeaders:     "Content-Type, X-Request-Id",
+		HeaderAllowCredentials: "true",
+		HeaderMaxAge:           "600",
+		HeaderVary:             varyPreflight,
+	}, output["headers"])
+
+	output, returned = evaluate(t, act, "GET", map[string]string{
+		"origin": "https://api.EXAMPLE.org",
+	})
+	assert.False(t, returned)
+	assert.True(t, output["allowed"].(bool))
+	assert.False(t, output["preflight"].(bool))
+	assert.Equal(t, map[string]string{
+		HeaderAllowOrigin:      "https://api.EXAMPLE.org",
+		HeaderAllowCredentials: "true",
+		HeaderExposeHeaders:    "X-Request-Id",
+		HeaderVary:             HeaderOrigin,
+	}, output["headers"])
+
+	output, returned = evaluate(t, act, "GET", nil)
+	assert.False(t, returned)
+	assert.True(t, output["allowed"].(bool), "same origin requests should be allowed")
+	assert.Equal(t, map[string]string{HeaderVary: HeaderOrigin}, output["headers"])
+
+	rejected := []struct {
+		method  string
+		headers map[string]string
+		message string
+	}{
+		{"GET", map[string]string{"Origin": "https://evil.com"}, "origin 'https://evil.com' is not allowed"},
+		{"GET", map[string]string{"Origin": "https://example.org"}, "origin 'https://example.org' is not allowed"},
+		{"OPTIONS", map[string]string{
+			"Origin":                        "https://app.example.com",
+			"Access-Control-Request-Method": "DELETE",
+		}, "method 'DELETE' is not allowed"},
+		{"OPTIONS", map[string]string{
+			"Origin":                         "https://app.example.com",
+			"Access-Control-Request-Method":  "GET",
+			"Access-Control-Request-Headers": "Authorization",
+		}, "header 'Authorization' is not allowed"},
+	}
+	for _, test := range rejected {
+		output, returned = evaluate(t, act, test.method, test.headers)
+		assert.False(t, output["allowed"].(bool), test.message)
+		assert.Equal(t, test.method == "OPTIONS", returned, test.message)
+		assert.Equal(t, test.message, output["rejectMessage"])
+		_, ok := output["headers"].(map[string]string)[HeaderAllowOrigin]
+		assert.False(t, ok, test.message)
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"allowOrigins": []interface{}{"*"},
+		"allowHeaders": []interface{}{"*"},
+	}))
+	assert.Nil(t, err)
+
+	output, returned := evaluate(t, act, "", map[string]string{
+		"Origin":                         "https://any.com",
+		"Access-Control-Request-Method":  "post",
+		"Access-Control-Request-Headers": "X-Custom",
+	})
+	assert.True(t, returned)
+	assert.True(t, output["allowed"].(bool))
+	assert.Equal(t, map[string]string{
+		HeaderAllowOrigin:  "*",
+		HeaderAllowMethods: "GET, HEAD, POST",
+		HeaderAllowHeaders: "X-Custom",
+		HeaderVary:         varyPreflight,
+	}, output["headers"])
+
+	output, _ = evaluate(t, act, "GET", map[string]string{
+		"Origin": "https://any.com",
+	})
+	assert.Equal(t, map[string]string{HeaderAllowOrigin: "*"}, output["headers"])
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"allowOrigins":     []interface{}{"*"},
+		"allowCredentials": true,
+	}))
+	assert.NotNil(t, err)
+	_, err = New(newInitContext(map[string]interface{}{
+		"allowOrigins": []interface{}{"https://*.*.com"},
+	}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,87 @@
+package cors
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the CORS policy
+type Settings struct {
+	AllowOrigins     []interface{} `md:"allowOrigins"`
+	AllowMethods     []interface{} `md:"allowMethods"`
+	AllowHeaders     []interface{} `md:"allowHeaders"`
+	ExposeHeaders    []interface{} `md:"exposeHeaders"`
+	AllowCredentials bool          `md:"allowCredentials"`
+	MaxAge           int           `md:"maxAge"`
+}
+
+// Input is the input for the CORS policy
+type Input struct {
+	Method  string            `md:"method"`
+	Headers map[string]string `md:"headers"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	method, err := coerce.ToString(values["method"])
+	if err != nil {
+		return err
+	}
+	r.Method = method
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"method":  r.Method,
+		"headers": r.Headers,
+	}
+}
+
+// Output is the output of the CORS policy
+type Output struct {
+	Allowed       bool              `md:"allowed"`
+	Preflight     bool              `md:"preflight"`
+	Headers       map[string]string `md:"headers"`
+	RejectMessage string            `md:"rejectMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	allowed, err := coerce.ToBool(values["allowed"])
+	if err != nil {
+		return err
+	}
+	o.Allowed = allowed
+	preflight, err := coerce.ToBool(values["preflight"])
+	if err != nil {
+		return err
+	}
+	o.Preflight = preflight
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.Headers = headers
+	rejectMessage, err := coerce.ToString(values["rejectMessage"])
+	if err != nil {
+		return err
+	}
+	o.RejectMessage = rejectMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"allowed":       o.Allowed,
+		"preflight":     o.Preflight,
+		"headers":       o.Headers,
+		"rejectMessage": o.RejectMessage,
+	}
+}
@@ -0,0 +1,89 @@
+package headers
+
+import (
+	"fmt"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new header manipulator
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	operations, err := ParseOperations(settings.Operations)
+	if err != nil {
+		return nil, err
+	}
+	act := &Activity{
+		operations: operations,
+	}
+	return act, nil
+}
+
+// Activity is a header manipulator which modifies the headers and query parameters of a request
+type Activity struct {
+	operations []*Operation
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := Output{
+		Headers:     copyValues(input.Headers),
+		QueryParams: copyValues(input.QueryParams),
+	}
+	scope := ctx.ActivityHost().Scope()
+	for i, operation := range a.operations {
+		values := output.Headers
+		if operation.In == InQueryParams {
+			values = output.QueryParams
+		}
+		err := operation.Apply(values, scope)
+		if err != nil {
+			output.Error = true
+			output.ErrorMessage = fmt.Sprintf("operation %d on %s failed: %v", i, operation.Name, err)
+			break
+		}
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copyValues copies the values so that the input is never modified
+func copyValues(values map[string]string) map[string]string {
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
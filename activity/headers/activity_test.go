@@ -0,0 +1,192 @@
+package headers
+
+import (
+	"testing"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+	scope  data.Scope
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return a.scope
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func TestHeaders(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"operations": []interface{}{
+			map[string]interface{}{"op": "setIfAbsent", "name": "X-Request-ID", "value": "=$.payload.requestId"},
+			map[string]interface{}{"op": "append", "name": "X-Forwarded-For", "value": "=$.payload.clientIP"},
+			map[string]interface{}{"op": "remove", "name": "authorization"},
+			map[string]interface{}{"op": "remove", "name": "X-Internal-*"},
+			map[string]interface{}{"op": "rename", "name": "X-Api-Version", "to": "Accept-Version"},
+			map[string]interface{}{"op": "set", "name": "x-gateway", "value": "microgateway"},
+			map[string]interface{}{"op": "set", "name": "X-Missing", "value": "=$.payload.missing"},
+			map[string]interface{}{"op": "set", "in": "queryParams", "name": "version", "value": "2"},
+			map[string]interface{}{"op": "append", "in": "queryParams", "name": "fields", "value": "id"},
+			map[string]interface{}{"op": "remove", "in": "queryParams", "name": "Token"},
+		},
+	}))
+	assert.Nil(t, err)
+
+	headers := map[string]string{
+		"Authorization":   "Bearer secret",
+		"X-Forwarded-For": "10.0.0.1",
+		"X-Internal-User": "admin",
+		"x-internal-role": "root",
+		"X-Api-Version":   "2",
+		"Accept-Version":  "1",
+		"X-Gateway":       "other",
+	}
+	ctx := newActivityContext(map[string]interface{}{
+		"headers":     headers,
+		"queryParams": map[string]string{"fields": "name", "token": "secret", "Token": "secret"},
+	})
+	ctx.scope = data.NewSimpleScope(map[string]interface{}{
+		"payload": map[string]interface{}{
+			"requestId": "abc",
+			"clientIP":  "192.168.1.1",
+		},
+	}, nil)
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, false, ctx.output["error"])
+	assert.Equal(t, map[string]string{
+		"X-Request-ID":    "abc",
+		"X-Forwarded-For": "10.0.0.1, 192.168.1.1",
+		"Accept-Version":  "2",
+		"X-Gateway":       "microgateway",
+	}, ctx.output["headers"])
+	assert.Equal(t, map[string]string{
+		"fields":  "name,id",
+		"version": "2",
+		"token":   "secret",
+	}, ctx.output["queryParams"])
+	assert.Equal(t, "Bearer secret", headers["Authorization"], "the input should not be modified")
+
+	ctx = newActivityContext(map[string]interface{}{
+		"headers": map[string]string{"x-request-id": "def"},
+	})
+	ctx.scope = data.NewSimpleScope(map[string]interface{}{
+		"payload": map[string]interface{}{"requestId": "abc"},
+	}, nil)
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"x-request-id": "def",
+		"x-gateway":    "microgateway",
+	}, ctx.output["headers"])
+
+	for _, operation := range []map[string]interface{}{
+		{"op": "replace", "name": "A"},
+		{"op": "set"},
+		{"op": "rename", "name": "A"},
+		{"op": "set", "name": "A", "in": "body"},
+		{"op": "set", "name": "A", "value": "=$.payload.("},
+		{"op": "set", "name": "A", "unknown": true},
+	} {
+		_, err = New(newInitContext(map[string]interface{}{
+			"operations": []interface{}{operation},
+		}))
+		assert.NotNil(t, err, operation)
+	}
+}
@@ -0,0 +1,82 @@
+package headers
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the header manipulator
+type Settings struct {
+	Operations []interface{} `md:"operations,required"`
+}
+
+// Input is the input for the header manipulator
+type Input struct {
+	Headers     map[string]string `md:"headers"`
+	QueryParams map[string]string `md:"queryParams"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	queryParams, err := coerce.ToParams(values["queryParams"])
+	if err != nil {
+		return err
+	}
+	r.QueryParams = queryParams
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"headers":     r.Headers,
+		"queryParams": r.QueryParams,
+	}
+}
+
+// Output is the output of the header manipulator
+type Output struct {
+	Headers      map[string]string `md:"headers"`
+	QueryParams  map[string]string `md:"queryParams"`
+	Error        bool              `md:"error"`
+	ErrorMessage string            `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.Headers = headers
+	queryParams, err := coerce.ToParams(values["queryParams"])
+	if err != nil {
+		return err
+	}
+	o.QueryParams = queryParams
+	hasError, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = hasError
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"headers":      o.Headers,
+		"queryParams":  o.QueryParams,
+		"error":        o.Error,
+		"errorMessage": o.ErrorMessage,
+	}
+}
@@ -0,0 +1,193 @@
+package headers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/coerce"
+	"github.com/project-flogo/core/data/expression"
+	_ "github.com/project-flogo/core/data/expression/script"
+	"github.com/project-flogo/core/data/resolve"
+)
+
+const (
+	// OpSet sets a value, replacing the existing value
+	OpSet = "set"
+	// OpAppend appends a value to the existing value
+	OpAppend = "append"
+	// OpRemove removes a value
+	OpRemove = "remove"
+	// OpRename renames a value
+	OpRename = "rename"
+	// OpSetIfAbsent sets a value when there is no existing value
+	OpSetIfAbsent = "setIfAbsent"
+	// InHeaders is the location of the headers
+	InHeaders = "headers"
+	// InQueryParams is the location of the query parameters
+	InQueryParams = "queryParams"
+)
+
+var expressionFactory = expression.NewFactory(resolve.GetBasicResolver())
+
+// Operation is an operation on the headers or query parameters of a request
+type Operation struct {
+	Op    string
+	In    string
+	Name  string
+	To    string
+	Value string
+	expr  expression.Expr
+}
+
+// ParseOperations parses the operations from the settings
+func ParseOperations(values []interface{}) ([]*Operation, error) {
+	operations := make([]*Operation, 0, len(values))
+	for i, value := range values {
+		settings, err := coerce.ToObject(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation %d: %v", i, err)
+		}
+		operation := &Operation{}
+		for key, setting := range settings {
+			switch key {
+			case "op":
+				operation.Op, err = coerce.ToString(setting)
+			case "in":
+				operation.In, err = coerce.ToString(setting)
+			case "name":
+				operation.Name, err = coerce.ToString(setting)
+			case "to":
+				operation.To, err = coerce.ToString(setting)
+			case "value":
+				operation.Value, err = coerce.ToString(setting)
+			default:
+				err = fmt.Errorf("unknown field '%s'", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid operation %d: %v", i, err)
+			}
+		}
+
+		switch operation.In {
+		case "":
+			operation.In = InHeaders
+		case InHeaders, InQueryParams:
+		default:
+			return nil, fmt.Errorf("invalid operation %d: unknown location '%s'", i, operation.In)
+		}
+		if operation.Name == "" {
+			return nil, fmt.Errorf("invalid operation %d: a name is required", i)
+		}
+		switch operation.Op {
+		case OpSet, OpAppend, OpSetIfAbsent:
+			if strings.HasPrefix(operation.Value, "=") {
+				operation.expr, err = expressionFactory.NewExpr(operation.Value[1:])
+				if err != nil {
+					return nil, fmt.Errorf("invalid expression for operation %d: %v", i, err)
+				}
+			}
+		case OpRename:
+			if operation.To == "" {
+				return nil, fmt.Errorf("invalid operation %d: rename needs to", i)
+			}
+		case OpRemove:
+		default:
+			return nil, fmt.Errorf("invalid operation %d: unknown op '%s'", i, operation.Op)
+		}
+		operations = append(operations, operation)
+	}
+	return operations, nil
+}
+
+// Evaluate evaluates the value of the operation in the scope, the value is nil when
+// the expression evaluates to nil
+func (o *Operation) Evaluate(scope data.Scope) (*string, error) {
+	if o.expr == nil {
+		return &o.Value, nil
+	}
+	value, err := o.expr.Eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	s, err := coerce.ToString(value)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Apply applies the operation to the values. Header names are case insensitive, and a name which
+// ends with * matches the names with the prefix for remove
+func (o *Operation) Apply(values map[string]string, scope data.Scope) error {
+	fold := o.In == InHeaders
+	separator := ","
+	if fold {
+		separator = ", "
+	}
+	key, found := lookup(values, o.Name, fold)
+	switch o.Op {
+	case OpSet, OpAppend, OpSetIfAbsent:
+		if o.Op == OpSetIfAbsent && found {
+			return nil
+		}
+		value, err := o.Evaluate(scope)
+		if err != nil {
+			return fmt.Errorf("unable to evaluate %s: %v", o.Value, err)
+		}
+		if value == nil {
+			return nil
+		}
+		if !found {
+			values[o.Name] = *value
+			return nil
+		}
+		if o.Op == OpAppend && values[key] != "" {
+			values[key] += separator + *value
+			return nil
+		}
+		values[key] = *value
+	case OpRemove:
+		if strings.HasSuffix(o.Name, "*") {
+			prefix := strings.TrimSuffix(o.Name, "*")
+			for name := range values {
+				if len(name) >= len(prefix) && (name[:len(prefix)] == prefix || (fold && strings.EqualFold(name[:len(prefix)], prefix))) {
+					delete(values, name)
+				}
+			}
+			return nil
+		}
+		if found {
+			delete(values, key)
+		}
+	case OpRename:
+		if !found {
+			return nil
+		}
+		value := values[key]
+		delete(values, key)
+		if to, ok := lookup(values, o.To, fold); ok {
+			delete(values, to)
+		}
+		values[o.To] = value
+	}
+	return nil
+}
+
+// lookup finds the key of a name in the values
+func lookup(values map[string]string, name string, fold bool) (string, bool) {
+	if _, ok := values[name]; ok {
+		return name, true
+	}
+	if fold {
+		for key := range values {
+			if strings.EqualFold(key, name) {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,276 @@
+package idempotency
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+	"github.com/project-flogo/microgateway/api"
+)
+
+const (
+	// OperationStart starts a request with an idempotency key
+	OperationStart = "start"
+	// OperationComplete completes a request with its response
+	OperationComplete = "complete"
+	// StateNew is the state of a request which is the first with its key
+	StateNew = "new"
+	// StateMismatch is the state of a request which reuses a key with a different request
+	StateMismatch = "mismatch"
+	// StateNone is the state of a request without a key
+	StateNone = "none"
+	// StateReleased is the state of a request which failed, so that it can be retried
+	StateReleased = "released"
+	// StateExpired is the state of a request which completed after its lock timed out, its response isn't stored
+	StateExpired = "expired"
+	// DefaultTTL is the default number of seconds a response is replayed
+	DefaultTTL = 86400
+	// DefaultLockTimeout is the default number of seconds a request can be in progress
+	DefaultLockTimeout = 60
+)
+
+var (
+	// ErrorKeyRequired happens when the idempotency key is required and empty
+	ErrorKeyRequired = errors.New("idempotency key is required")
+	// ErrorScopeRequired happens when a request with an idempotency key has no scope
+	ErrorScopeRequired = errors.New("idempotency scope is required")
+	activityMetadata   = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+	// Now returns the current time
+	Now = time.Now
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates new idempotency keys
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{
+		TTL:         DefaultTTL,
+		LockTimeout: DefaultLockTimeout,
+	}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	if settings.TTL <= 0 || settings.LockTimeout <= 0 {
+		return nil, errors.New("ttl and lockTimeout should be greater than 0")
+	}
+	store, err := NewStore(&settings)
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{
+		ttl:         time.Duration(settings.TTL) * time.Second,
+		lockTimeout: time.Duration(settings.LockTimeout) * time.Second,
+		required:    settings.KeyRequired,
+		store:       store,
+		started:     make(map[start]lock, 256),
+	}
+	return act, nil
+}
+
+// start identifies a request started by a microgateway execution
+type start struct {
+	host activity.Host
+	key  string
+}
+
+// lock is the record in progress of a started request
+type lock struct {
+	fingerprint, owner string
+}
+
+// Activity deduplicates requests with idempotency keys, replaying the responses of completed requests
+type Activity struct {
+	ttl, lockTimeout time.Duration
+	required         bool
+	store            Store
+
+	sync.Mutex
+	started map[start]lock
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := Output{}
+	if input.Key == "" {
+		if a.required {
+			output.Error = true
+			output.ErrorMessage = ErrorKeyRequired.Error()
+		} else {
+			output.State = StateNone
+		}
+		err = ctx.SetOutputObject(&output)
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	host, key := ctx.ActivityHost(), StoreKey(input.Scope, input.Key)
+	switch {
+	case input.Scope == "":
+		err = ErrorScopeRequired
+	case input.Operation == "" || input.Operation == OperationStart:
+		err = a.start(host, key, &input, &output)
+	case input.Operation == OperationComplete:
+		err = a.complete(host, key, &input, &output)
+	default:
+		return false, fmt.Errorf("unknown operation: %s", input.Operation)
+	}
+	if err != nil {
+		output.Error = true
+		output.ErrorMessage = err.Error()
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// start records the request as in progress, or returns the state of the existing record.
+// A retry is only given the stored response when both requests have the same fingerprint
+func (a *Activity) start(host activity.Host, key string, input *Input, output *Output) error {
+	fingerprint, err := Fingerprint(input.Request)
+	if err != nil {
+		return err
+	}
+	owner, err := newOwner()
+	if err != nil {
+		return err
+	}
+	existing, err := a.store.Start(key, &Record{
+		State:       StateInProgress,
+		Owner:       owner,
+		Fingerprint: fingerprint,
+		Expires:     Now().Add(a.lockTimeout),
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case existing == nil:
+		output.State = StateNew
+		started := start{host: host, key: key}
+		a.Lock()
+		a.started[started] = lock{fingerprint: fingerprint, owner: owner}
+		a.Unlock()
+		// release the key when the execution ends without completing the request,
+		// unless the lock has timed out and the key belongs to a retry
+		if deferrer, ok := host.(api.Deferrer); ok {
+			deferrer.Defer(func() {
+				if _, ok := a.finish(started); ok {
+					a.store.Delete(key, owner)
+				}
+			})
+		}
+	case existing.Fingerprint == "" || fingerprint == "" || existing.Fingerprint != fingerprint:
+		output.State = StateMismatch
+	case existing.State == StateCompleted:
+		output.State = StateCompleted
+		output.Code, output.Data, output.Headers = existing.Code, existing.Data, existing.Headers
+	default:
+		output.State = StateInProgress
+	}
+	return nil
+}
+
+// complete stores the response of a successful request, and releases the key of a failed request.
+// The record is only changed while it is still the one started by the request
+func (a *Activity) complete(host activity.Host, key string, input *Input, output *Output) error {
+	started, ok := a.finish(start{host: host, key: key})
+	if !ok {
+		return fmt.Errorf("request with key %s was not started", input.Key)
+	}
+
+	var err error
+	if input.Code != 0 && (input.Code < 200 || input.Code > 299) {
+		output.State = StateReleased
+		err = a.store.Delete(key, started.owner)
+	} else {
+		output.State = StateCompleted
+		output.Code, output.Data, output.Headers = input.Code, input.Data, input.Headers
+		err = a.store.Complete(key, &Record{
+			State:       StateCompleted,
+			Owner:       started.owner,
+			Fingerprint: started.fingerprint,
+			Code:        input.Code,
+			Data:        input.Data,
+			Headers:     input.Headers,
+			Expires:     Now().Add(a.ttl),
+		})
+	}
+	if err == ErrorLockExpired {
+		*output = Output{State: StateExpired}
+		return nil
+	}
+	return err
+}
+
+// finish forgets a request started by an execution, and returns its lock and if it was started
+func (a *Activity) finish(key start) (lock, bool) {
+	a.Lock()
+	defer a.Unlock()
+	started, ok := a.started[key]
+	if ok {
+		delete(a.started, key)
+	}
+	return started, ok
+}
+
+// newOwner returns a random owner for a record in progress
+func newOwner() (string, error) {
+	owner := make([]byte, 16)
+	_, err := rand.Read(owner)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(owner), nil
+}
+
+// StoreKey returns the key of the record of a request, the idempotency key is scoped to the
+// caller so that callers reusing a key never get the responses of each other
+func StoreKey(scope, key string) string {
+	return url.QueryEscape(scope) + ":" + key
+}
+
+// Fingerprint returns the hash of the JSON encoding of a request, or an empty string without a request
+func Fingerprint(request interface{}) (string, error) {
+	if request == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,358 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input    map[string]interface{}
+	output   map[string]interface{}
+	deferred []func()
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func (a *activityContext) Defer(f func()) {
+	a.deferred = append(a.deferred, f)
+}
+
+func (a *activityContext) runDeferred() {
+	for _, f := range a.deferred {
+		f()
+	}
+	a.deferred = nil
+}
+
+func TestIdempotency(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	Now = func() time.Time {
+		return now
+	}
+	defer func() {
+		Now = time.Now
+	}()
+
+	test := func(settings map[string]interface{}, advance func(time.Duration)) {
+		settings["ttl"] = 600
+		settings["lockTimeout"] = 30
+		act, err := New(newInitContext(settings))
+		assert.Nil(t, err)
+
+		eval := func(input map[string]interface{}) (*activityContext, map[string]interface{}) {
+			ctx := newActivityContext(input)
+			_, err := act.Eval(ctx)
+			assert.Nil(t, err)
+			return ctx, ctx.output
+		}
+		startScope := func(scope, key string, request interface{}) (*activityContext, map[string]interface{}) {
+			return eval(map[string]interface{}{
+				"operation": "start",
+				"key":       key,
+				"scope":     scope,
+				"request":   request,
+			})
+		}
+		start := func(key string, request interface{}) (*activityContext, map[string]interface{}) {
+			return startScope("client1", key, request)
+		}
+		complete := func(ctx *activityContext, key string, code int) map[string]interface{} {
+			ctx.input = map[string]interface{}{
+				"operation": "complete",
+				"key":       key,
+				"scope":     "client1",
+				"code":      code,
+				"data":      map[string]interface{}{"id": "pet1"},
+				"headers":   map[string]string{"Location": "/pets/pet1"},
+			}
+			_, err := act.Eval(ctx)
+			assert.Nil(t, err)
+			return ctx.output
+		}
+		request := map[string]interface{}{"name": "sally"}
+
+		first, output := start("a", request)
+		assert.Equal(t, StateNew, output["state"])
+		_, output = start("a", request)
+		assert.Equal(t, StateInProgress, output["state"])
+		_, output = start("a", map[string]interface{}{"name": "bob"})
+		assert.Equal(t, StateMismatch, output["state"])
+
+		output = complete(first, "a", 201)
+		assert.Equal(t, StateCompleted, output["state"])
+		assert.Equal(t, false, output["error"])
+		first.runDeferred()
+		_, output = start("a", request)
+		assert.Equal(t, StateCompleted, output["state"])
+		assert.Equal(t, 201, output["code"])
+		assert.Equal(t, map[string]interface{}{"id": "pet1"}, output["data"])
+		assert.Equal(t, map[string]string{"Location": "/pets/pet1"}, output["headers"])
+		_, output = start("b", request)
+		assert.Equal(t, StateNew, output["state"], "keys are independent")
+		_, output = startScope("client2", "a", request)
+		assert.Equal(t, StateNew, output["state"], "keys are scoped to the caller")
+		_, output = startScope("client1", "x:y", request)
+		assert.Equal(t, StateNew, output["state"])
+		_, output = startScope("client1:x", "y", request)
+		assert.Equal(t, StateNew, output["state"], "scopes can't collide with keys")
+		_, output = start("a", nil)
+		assert.Equal(t, StateMismatch, output["state"], "a retry without a request should not get the response")
+
+		// the response is replayed until the ttl
+		advance(601 * time.Second)
+		ctx, output := start("a", request)
+		assert.Equal(t, StateNew, output["state"])
+
+		// a request which ends without completing is released
+		ctx.runDeferred()
+		ctx, output = start("a", request)
+		assert.Equal(t, StateNew, output["state"])
+
+		// a failed request is released
+		output = complete(ctx, "a", 503)
+		assert.Equal(t, StateReleased, output["state"])
+		ctx.runDeferred()
+		expired, output := start("a", request)
+		assert.Equal(t, StateNew, output["state"])
+
+		// a request in progress is released after the lock timeout
+		_, output = start("a", request)
+		assert.Equal(t, StateInProgress, output["state"])
+		advance(31 * time.Second)
+		retry, output := start("a", request)
+		assert.Equal(t, StateNew, output["state"])
+
+		// the expired request can't complete or release the record of the retry
+		output = complete(expired, "a", 201)
+		assert.Equal(t, StateExpired, output["state"])
+		assert.Equal(t, false, output["error"])
+		expired.runDeferred()
+		_, output = start("a", request)
+		assert.Equal(t, StateInProgress, output["state"], "the retry should still be in progress")
+		output = complete(retry, "a", 200)
+		assert.Equal(t, StateCompleted, output["state"])
+		retry.runDeferred()
+		_, output = start("a", request)
+		assert.Equal(t, StateCompleted, output["state"])
+		assert.Equal(t, 200, output["code"])
+
+		// an expired request which fails doesn't release the key of a retry either
+		expired, output = start("d", request)
+		assert.Equal(t, StateNew, output["state"])
+		advance(31 * time.Second)
+		retry, output = start("d", request)
+		assert.Equal(t, StateNew, output["state"])
+		output = complete(expired, "d", 503)
+		assert.Equal(t, StateExpired, output["state"])
+		_, output = start("d", request)
+		assert.Equal(t, StateInProgress, output["state"])
+		retry.runDeferred()
+		_, output = start("d", request)
+		assert.Equal(t, StateNew, output["state"], "the retry should release the key when it ends")
+
+		output = complete(newActivityContext(nil), "c", 200)
+		assert.Equal(t, true, output["error"])
+		assert.Equal(t, "request with key c was not started", output["errorMessage"])
+
+		_, output = start("", request)
+		assert.Equal(t, StateNone, output["state"])
+	}
+
+	test(map[string]interface{}{}, func(d time.Duration) {
+		now = now.Add(d)
+	})
+
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer server.Close()
+	test(map[string]interface{}{
+		"store":    "redis",
+		"storeUrl": "redis://" + server.Addr(),
+	}, func(d time.Duration) {
+		now = now.Add(d)
+		server.FastForward(d)
+	})
+}
+
+func TestIdempotencySettings(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"keyRequired": true,
+	}))
+	assert.Nil(t, err)
+	ctx := newActivityContext(map[string]interface{}{})
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, true, ctx.output["error"])
+	assert.Equal(t, ErrorKeyRequired.Error(), ctx.output["errorMessage"])
+
+	ctx = newActivityContext(map[string]interface{}{"key": "a", "request": "pet"})
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, true, ctx.output["error"])
+	assert.Equal(t, ErrorScopeRequired.Error(), ctx.output["errorMessage"])
+
+	// without a request retries are mismatches, as they can't be compared
+	ctx = newActivityContext(map[string]interface{}{"key": "a", "scope": "client1"})
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, StateNew, ctx.output["state"])
+	ctx = newActivityContext(map[string]interface{}{"key": "a", "scope": "client1"})
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, StateMismatch, ctx.output["state"])
+	ctx = newActivityContext(map[string]interface{}{"key": "a", "scope": "client1", "request": "pet"})
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, StateMismatch, ctx.output["state"])
+
+	for _, settings := range []map[string]interface{}{
+		{"ttl": -1},
+		{"lockTimeout": 0},
+		{"store": "unknown"},
+		{"store": "redis"},
+	} {
+		_, err := New(newInitContext(settings))
+		assert.NotNil(t, err, settings)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	Now = func() time.Time {
+		return now
+	}
+	defer func() {
+		Now = time.Now
+	}()
+
+	store := NewMemoryStore()
+	for i := 0; i < sweepInterval-1; i++ {
+		existing, err := store.Start(string(rune('a'+i%26))+time.Duration(i).String(), &Record{
+			State:   StateInProgress,
+			Expires: now.Add(time.Second),
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, existing)
+	}
+	assert.Equal(t, sweepInterval-1, store.Len())
+
+	// expired records are removed periodically
+	now = now.Add(2 * time.Second)
+	_, err := store.Start("last", &Record{State: StateInProgress, Expires: now.Add(time.Second)})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestRedisStore(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer server.Close()
+
+	store, err := NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "")
+	assert.Nil(t, err)
+	existing, err := store.Start("a", &Record{State: StateInProgress, Expires: Now().Add(-time.Second)})
+	assert.NotNil(t, err, "an expired record should not be reported as a new request")
+	assert.Nil(t, existing)
+	assert.False(t, server.Exists(DefaultStorePrefix+":a"))
+}
@@ -0,0 +1,124 @@
+package idempotency
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the idempotency keys
+type Settings struct {
+	TTL         int    `md:"ttl"`
+	LockTimeout int    `md:"lockTimeout"`
+	KeyRequired bool   `md:"keyRequired"`
+	Store       string `md:"store"`
+	StoreURL    string `md:"storeUrl"`
+	StorePrefix string `md:"storePrefix"`
+}
+
+// Input is the input for the idempotency keys
+type Input struct {
+	Operation string            `md:"operation,allowed(start,complete)"`
+	Key       string            `md:"key"`
+	Scope     string            `md:"scope"`
+	Request   interface{}       `md:"request"`
+	Code      int               `md:"code"`
+	Data      interface{}       `md:"data"`
+	Headers   map[string]string `md:"headers"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	operation, err := coerce.ToString(values["operation"])
+	if err != nil {
+		return err
+	}
+	r.Operation = operation
+	key, err := coerce.ToString(values["key"])
+	if err != nil {
+		return err
+	}
+	r.Key = key
+	scope, err := coerce.ToString(values["scope"])
+	if err != nil {
+		return err
+	}
+	r.Scope = scope
+	r.Request = values["request"]
+	code, err := coerce.ToInt(values["code"])
+	if err != nil {
+		return err
+	}
+	r.Code = code
+	r.Data = values["data"]
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"operation": r.Operation,
+		"key":       r.Key,
+		"scope":     r.Scope,
+		"request":   r.Request,
+		"code":      r.Code,
+		"data":      r.Data,
+		"headers":   r.Headers,
+	}
+}
+
+// Output is the output of the idempotency keys
+type Output struct {
+	State        string            `md:"state"`
+	Code         int               `md:"code"`
+	Data         interface{}       `md:"data"`
+	Headers      map[string]string `md:"headers"`
+	Error        bool              `md:"error"`
+	ErrorMessage string            `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	state, err := coerce.ToString(values["state"])
+	if err != nil {
+		return err
+	}
+	o.State = state
+	code, err := coerce.ToInt(values["code"])
+	if err != nil {
+		return err
+	}
+	o.Code = code
+	o.Data = values["data"]
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.Headers = headers
+	hasError, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = hasError
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"state":        o.State,
+		"code":         o.Code,
+		"data":         o.Data,
+		"headers":      o.Headers,
+		"error":        o.Error,
+		"errorMessage": o.ErrorMessage,
+	}
+}
@@ -0,0 +1,284 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	// StoreMemory keeps the records in process memory
+	StoreMemory = "memory"
+	// StoreRedis keeps the records in a Redis compatible server
+	StoreRedis = "redis"
+	// DefaultStorePrefix is the default prefix for keys in the store
+	DefaultStorePrefix = "flogo:idempotency"
+	// StateInProgress is the state of a record for a request which is running
+	StateInProgress = "inProgress"
+	// StateCompleted is the state of a record holding the response of a request
+	StateCompleted = "completed"
+	// sweepInterval is the number of records started between removals of the expired records
+	sweepInterval = 1024
+	// storeMaxRetry is the maximum number of retries of the redis store
+	storeMaxRetry = 3
+)
+
+var (
+	// ErrorLockExpired happens when a record is no longer in progress for the request which started it,
+	// because the lock timed out and the record expired or was started again by a retry
+	ErrorLockExpired = errors.New("the lock of the request has expired")
+)
+
+// Record is the record of a request with an idempotency key
+type Record struct {
+	State       string            `json:"state"`
+	Owner       string            `json:"owner"`
+	Fingerprint string            `json:"fingerprint"`
+	Code        int               `json:"code"`
+	Data        interface{}       `json:"data"`
+	Headers     map[string]string `json:"headers"`
+	// Expires is when the record is removed
+	Expires time.Time `json:"expires"`
+}
+
+// Store holds the records of the requests, possibly shared between gateway replicas
+type Store interface {
+	// Start stores the record for key unless there is a record for key already, which is returned
+	Start(key string, record *Record) (*Record, error)
+	// Complete stores the completed record for key, replacing the record in progress with the same owner.
+	// ErrorLockExpired is returned when there is no such record
+	Complete(key string, record *Record) error
+	// Delete deletes the record in progress for key with the given owner.
+	// ErrorLockExpired is returned when there is no such record
+	Delete(key, owner string) error
+}
+
+// StoreFactory creates a store from the settings
+type StoreFactory func(settings *Settings) (Store, error)
+
+var (
+	storesLock sync.RWMutex
+	stores     = map[string]StoreFactory{
+		StoreMemory: func(settings *Settings) (Store, error) {
+			return NewMemoryStore(), nil
+		},
+		StoreRedis: func(settings *Settings) (Store, error) {
+			if settings.StoreURL == "" {
+				return nil, errors.New("storeUrl is required for the redis store")
+			}
+			options, err := redis.ParseURL(settings.StoreURL)
+			if err != nil {
+				return nil, err
+			}
+			return NewRedisStore(redis.NewClient(options), settings.StorePrefix)
+		},
+	}
+)
+
+// RegisterStore registers a store factory, allowing records to be kept in other systems
+func RegisterStore(name string, factory StoreFactory) {
+	storesLock.Lock()
+	defer storesLock.Unlock()
+	stores[name] = factory
+}
+
+// NewStore creates a new store of the kind given by the settings
+func NewStore(settings *Settings) (Store, error) {
+	kind := settings.Store
+	if kind == "" {
+		kind = StoreMemory
+	}
+	storesLock.RLock()
+	factory, ok := stores[kind]
+	storesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown store: %s", kind)
+	}
+	return factory(settings)
+}
+
+// MemoryStore is a store local to this process
+type MemoryStore struct {
+	sync.Mutex
+	records map[string]*Record
+	started int
+}
+
+// NewMemoryStore creates a new memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record, 256),
+	}
+}
+
+// Start stores the record for key unless there is an unexpired record for key
+func (m *MemoryStore) Start(key string, record *Record) (*Record, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	now := Now()
+	if existing, ok := m.records[key]; ok && now.Before(existing.Expires) {
+		return existing, nil
+	}
+	m.started++
+	if m.started >= sweepInterval {
+		m.started = 0
+		for k, existing := range m.records {
+			if !now.Before(existing.Expires) {
+				delete(m.records, k)
+			}
+		}
+	}
+	m.records[key] = record
+	return nil, nil
+}
+
+// Complete stores the completed record for key
+func (m *MemoryStore) Complete(key string, record *Record) error {
+	m.Lock()
+	defer m.Unlock()
+	if !m.owns(key, record.Owner) {
+		return ErrorLockExpired
+	}
+	m.records[key] = record
+	return nil
+}
+
+// Delete deletes the record in progress for key
+func (m *MemoryStore) Delete(key, owner string) error {
+	m.Lock()
+	defer m.Unlock()
+	if !m.owns(key, owner) {
+		return ErrorLockExpired
+	}
+	delete(m.records, key)
+	return nil
+}
+
+// owns returns if the record for key is an unexpired record in progress with the given owner
+func (m *MemoryStore) owns(key, owner string) bool {
+	existing, ok := m.records[key]
+	return ok && existing.State == StateInProgress && existing.Owner == owner && Now().Before(existing.Expires)
+}
+
+// Len returns the number of records in the store
+func (m *MemoryStore) Len() int {
+	m.Lock()
+	defer m.Unlock()
+	return len(m.records)
+}
+
+// RedisStore is a store backed by a Redis compatible server
+type RedisStore struct {
+	prefix string
+	client *redis.Client
+}
+
+// NewRedisStore creates a new redis store
+func NewRedisStore(client *redis.Client, prefix string) (*RedisStore, error) {
+	if prefix == "" {
+		prefix = DefaultStorePrefix
+	}
+	err := client.Ping().Err()
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{
+		prefix: prefix,
+		client: client,
+	}, nil
+}
+
+// Start stores the record for key unless there is a record for key
+func (r *RedisStore) Start(key string, record *Record) (*Record, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	ttl := record.Expires.Sub(Now())
+	if ttl <= 0 {
+		return nil, fmt.Errorf("record for key %s has already expired", key)
+	}
+	// the existing record can expire between SETNX and GET, in which case SETNX is tried again
+	for i := 0; i < storeMaxRetry; i++ {
+		stored, err := r.client.SetNX(r.prefix+":"+key, data, ttl).Result()
+		if err != nil {
+			return nil, err
+		} else if stored {
+			return nil, nil
+		}
+		existing, err := r.client.Get(r.prefix + ":" + key).Bytes()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		result := &Record{}
+		err = json.Unmarshal(existing, result)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unable to start the record for key %s", key)
+}
+
+// Complete stores the completed record for key until the record expires
+func (r *RedisStore) Complete(key string, record *Record) error {
+	ttl := record.Expires.Sub(Now())
+	if ttl <= 0 {
+		return r.Delete(key, record.Owner)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return r.update(key, record.Owner, func(pipe redis.Pipeliner, key string) {
+		pipe.Set(key, data, ttl)
+	})
+}
+
+// Delete deletes the record in progress for key
+func (r *RedisStore) Delete(key, owner string) error {
+	return r.update(key, owner, func(pipe redis.Pipeliner, key string) {
+		pipe.Del(key)
+	})
+}
+
+// update changes the record in progress for key with the given owner using optimistic locking
+func (r *RedisStore) update(key, owner string, change func(pipe redis.Pipeliner, key string)) error {
+	name := r.prefix + ":" + key
+	transaction := func(tx *redis.Tx) error {
+		data, err := tx.Get(name).Bytes()
+		if err == redis.Nil {
+			return ErrorLockExpired
+		} else if err != nil {
+			return err
+		}
+		existing := Record{}
+		err = json.Unmarshal(data, &existing)
+		if err != nil {
+			return err
+		}
+		if existing.State != StateInProgress || existing.Owner != owner {
+			return ErrorLockExpired
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			change(pipe, name)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < storeMaxRetry; i++ {
+		err := r.client.Watch(transaction, name)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return fmt.Errorf("unable to update the record for key %s", key)
+}
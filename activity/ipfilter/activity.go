@@ -0,0 +1,188 @@
+package ipfilter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/coerce"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+const (
+	// DefaultForwardedHeader is the default header holding the addresses of the client and proxies
+	DefaultForwardedHeader = "X-Forwarded-For"
+)
+
+var (
+	// ErrorNoClientIP happens when the IP address of the client can't be found
+	ErrorNoClientIP  = errors.New("client IP address not found")
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new IP filter
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	allow, err := toStrings(settings.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := toStrings(settings.Deny)
+	if err != nil {
+		return nil, err
+	}
+	proxies, err := toStrings(settings.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{
+		forwardedHeader: settings.ForwardedHeader,
+	}
+	act.rules, err = ParseRules(allow, deny)
+	if err != nil {
+		return nil, err
+	}
+	act.trustedProxies, err = ParseNetworks(proxies)
+	if err != nil {
+		return nil, err
+	}
+	if settings.File != "" {
+		act.file, err = NewFileRules(settings.File)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if act.forwardedHeader == "" {
+		act.forwardedHeader = DefaultForwardedHeader
+	}
+	return act, nil
+}
+
+// Activity is an IP filter
+type Activity struct {
+	rules           *Rules
+	file            *FileRules
+	trustedProxies  []*net.IPNet
+	forwardedHeader string
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := Output{}
+	ip, err := a.clientIP(&input)
+	if err != nil {
+		output.Error, output.ErrorMessage = true, err.Error()
+	} else {
+		rules := a.rules
+		if a.file != nil {
+			rules = rules.merge(a.file.Rules())
+		}
+		rule, allowed := rules.Match(ip)
+		output.Allowed, output.ClientIP = allowed, ip.String()
+		if rule != nil {
+			output.MatchedRule = rule.String()
+		}
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// clientIP resolves the IP address of the client. The forwarded header is only used when the
+// request comes from a trusted proxy, and is read from right to left skipping trusted proxies
+func (a *Activity) clientIP(input *Input) (net.IP, error) {
+	var ip net.IP
+	if input.RemoteAddress != "" {
+		ip = parseIP(input.RemoteAddress)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid remote address: %s", input.RemoteAddress)
+		}
+		if !a.trusted(ip) {
+			return ip, nil
+		}
+	} else if len(a.trustedProxies) == 0 {
+		return nil, ErrorNoClientIP
+	}
+
+	var forwarded []string
+	for name, value := range input.Headers {
+		if strings.EqualFold(name, a.forwardedHeader) {
+			forwarded = append(forwarded, strings.Split(value, ",")...)
+		}
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := parseIP(forwarded[i])
+		if hop == nil {
+			return nil, fmt.Errorf("invalid forwarded address: %s", strings.TrimSpace(forwarded[i]))
+		}
+		ip = hop
+		if !a.trusted(ip) {
+			break
+		}
+	}
+	if ip == nil {
+		return nil, ErrorNoClientIP
+	}
+	return ip, nil
+}
+
+// trusted checks if ip is a trusted proxy
+func (a *Activity) trusted(ip net.IP) bool {
+	for _, network := range a.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIP parses an IP address, with an optional port
+func parseIP(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	ip := net.ParseIP(strings.Trim(value, "[]"))
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+func toStrings(values []interface{}) ([]string, error) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		s, err := coerce.ToString(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
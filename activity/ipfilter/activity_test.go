@@ -0,0 +1,252 @@
+package ipfilter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func filter(t *testing.T, act activity.Activity, remoteAddress, forwarded string) map[string]interface{} {
+	values := map[string]interface{}{
+		"remoteAddress": remoteAddress,
+	}
+	if forwarded != "" {
+		values["headers"] = map[string]string{"x-forwarded-for": forwarded}
+	}
+	ctx := newActivityContext(values)
+	_, err := act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func TestIPFilter(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"allow": []interface{}{"10.0.0.0/8", "2001:db8::/32", "192.168.1.1"},
+		"deny":  []interface{}{"10.1.0.0/16"},
+	}))
+	assert.Nil(t, err)
+
+	tests := []struct {
+		address string
+		allowed bool
+		ip      string
+		rule    string
+	}{
+		{"10.2.3.4", true, "10.2.3.4", "allow 10.0.0.0/8"},
+		{"10.2.3.4:8080", true, "10.2.3.4", "allow 10.0.0.0/8"},
+		{"10.1.2.3", false, "10.1.2.3", "deny 10.1.0.0/16"},
+		{"::ffff:10.1.2.3", false, "10.1.2.3", "deny 10.1.0.0/16"},
+		{"192.168.1.1", true, "192.168.1.1", "allow 192.168.1.1/32"},
+		{"192.168.1.2", false, "192.168.1.2", ""},
+		{"[2001:db8::1]:443", true, "2001:db8::1", "allow 2001:db8::/32"},
+	}
+	for _, test := range tests {
+		output := filter(t, act, test.address, "")
+		assert.Equal(t, test.allowed, output["allowed"], test.address)
+		assert.Equal(t, test.ip, output["clientIp"], test.address)
+		assert.Equal(t, test.rule, output["matchedRule"], test.address)
+	}
+
+	output := filter(t, act, "", "10.2.3.4")
+	assert.False(t, output["allowed"].(bool))
+	assert.True(t, output["error"].(bool))
+	assert.Equal(t, ErrorNoClientIP.Error(), output["errorMessage"])
+
+	output = filter(t, act, "localhost", "")
+	assert.True(t, output["error"].(bool))
+
+	act, err = New(newInitContext(map[string]interface{}{
+		"deny": []interface{}{"203.0.113.0/24"},
+	}))
+	assert.Nil(t, err)
+	output = filter(t, act, "198.51.100.1", "")
+	assert.True(t, output["allowed"].(bool), "everything should be allowed without an allow list")
+	assert.Equal(t, "", output["matchedRule"])
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"allow": []interface{}{"10.0.0.0/33"},
+	}))
+	assert.NotNil(t, err)
+}
+
+func TestIPFilterForwarded(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"deny":           []interface{}{"203.0.113.0/24"},
+		"trustedProxies": []interface{}{"10.0.0.0/8"},
+	}))
+	assert.Nil(t, err)
+
+	tests := []struct {
+		address   string
+		forwarded string
+		ip        string
+	}{
+		{"198.51.100.1", "203.0.113.1", "198.51.100.1"},
+		{"10.0.0.1", "203.0.113.1", "203.0.113.1"},
+		{"10.0.0.1", "203.0.113.1, 10.0.0.2", "203.0.113.1"},
+		{"10.0.0.1", "203.0.113.1, 198.51.100.1, 10.0.0.2", "198.51.100.1"},
+		{"10.0.0.1", "10.0.0.3, 10.0.0.2", "10.0.0.3"},
+		{"10.0.0.1", "", "10.0.0.1"},
+		{"", "203.0.113.1, 10.0.0.2", "203.0.113.1"},
+	}
+	for _, test := range tests {
+		output := filter(t, act, test.address, test.forwarded)
+		assert.Equal(t, test.ip, output["clientIp"], test)
+		assert.Equal(t, test.ip != "203.0.113.1", output["allowed"], test)
+	}
+
+	output := filter(t, act, "10.0.0.1", "203.0.113.1, unknown")
+	assert.True(t, output["error"].(bool))
+}
+
+func TestIPFilterFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipfilter")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "rules.json")
+	err = ioutil.WriteFile(file, []byte(`{"allow": ["10.0.0.0/8"]}`), 0644)
+	assert.Nil(t, err)
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"allow": []interface{}{"192.168.0.0/16"},
+		"file":  file,
+	}))
+	assert.Nil(t, err)
+
+	output := filter(t, act, "10.1.1.1", "")
+	assert.True(t, output["allowed"].(bool))
+	assert.Equal(t, "allow 10.0.0.0/8", output["matchedRule"])
+	output = filter(t, act, "192.168.1.1", "")
+	assert.True(t, output["allowed"].(bool))
+
+	reload := func(content string) {
+		err := ioutil.WriteFile(file, []byte(content), 0644)
+		assert.Nil(t, err)
+		later := time.Now().Add(time.Minute)
+		err = os.Chtimes(file, later, later)
+		assert.Nil(t, err)
+		act.(*Activity).file.Reload()
+	}
+
+	reload(`{"allow": ["10.0.0.0/8"], "deny": ["10.1.0.0/16"]}`)
+	output = filter(t, act, "10.1.1.1", "")
+	assert.False(t, output["allowed"].(bool))
+	assert.Equal(t, "deny 10.1.0.0/16", output["matchedRule"])
+
+	reload(`{"deny": ["invalid"]}`)
+	output = filter(t, act, "10.1.1.1", "")
+	assert.False(t, output["allowed"].(bool), "invalid files should keep the previous rules")
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"file": filepath.Join(dir, "missing.json"),
+	}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,93 @@
+package ipfilter
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the IP filter
+type Settings struct {
+	Allow           []interface{} `md:"allow"`
+	Deny            []interface{} `md:"deny"`
+	File            string        `md:"file"`
+	TrustedProxies  []interface{} `md:"trustedProxies"`
+	ForwardedHeader string        `md:"forwardedHeader"`
+}
+
+// Input is the input for the IP filter
+type Input struct {
+	RemoteAddress string            `md:"remoteAddress"`
+	Headers       map[string]string `md:"headers"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	remoteAddress, err := coerce.ToString(values["remoteAddress"])
+	if err != nil {
+		return err
+	}
+	r.RemoteAddress = remoteAddress
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"remoteAddress": r.RemoteAddress,
+		"headers":       r.Headers,
+	}
+}
+
+// Output is the output of the IP filter
+type Output struct {
+	Allowed      bool   `md:"allowed"`
+	ClientIP     string `md:"clientIp"`
+	MatchedRule  string `md:"matchedRule"`
+	Error        bool   `md:"error"`
+	ErrorMessage string `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	allowed, err := coerce.ToBool(values["allowed"])
+	if err != nil {
+		return err
+	}
+	o.Allowed = allowed
+	clientIP, err := coerce.ToString(values["clientIp"])
+	if err != nil {
+		return err
+	}
+	o.ClientIP = clientIP
+	matchedRule, err := coerce.ToString(values["matchedRule"])
+	if err != nil {
+		return err
+	}
+	o.MatchedRule = matchedRule
+	e, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = e
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"allowed":      o.Allowed,
+		"clientIp":     o.ClientIP,
+		"matchedRule":  o.MatchedRule,
+		"error":        o.Error,
+		"errorMessage": o.ErrorMessage,
+	}
+}
@@ -0,0 +1,150 @@
+package ipfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/project-flogo/microgateway/internal/watch"
+)
+
+const (
+	// ListAllow is the name of the allow list
+	ListAllow = "allow"
+	// ListDeny is the name of the deny list
+	ListDeny = "deny"
+)
+
+// Rule is a network of an allow or deny list
+type Rule struct {
+	List    string
+	Network *net.IPNet
+}
+
+// String returns the list and network of the rule
+func (r *Rule) String() string {
+	return r.List + " " + r.Network.String()
+}
+
+// Rules are allow and deny lists
+type Rules struct {
+	Allow []*Rule
+	Deny  []*Rule
+}
+
+// ParseNetwork parses a CIDR or a single IP address
+func ParseNetwork(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address: %s", value)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+// ParseNetworks parses a list of CIDRs or IP addresses
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		network, err := ParseNetwork(value)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ParseRules parses allow and deny lists
+func ParseRules(allow, deny []string) (*Rules, error) {
+	rules := &Rules{}
+	for _, list := range []struct {
+		name   string
+		values []string
+		rules  *[]*Rule
+	}{
+		{ListAllow, allow, &rules.Allow},
+		{ListDeny, deny, &rules.Deny},
+	} {
+		networks, err := ParseNetworks(list.values)
+		if err != nil {
+			return nil, err
+		}
+		for _, network := range networks {
+			*list.rules = append(*list.rules, &Rule{List: list.name, Network: network})
+		}
+	}
+	return rules, nil
+}
+
+// Match returns the rule matching ip: deny rules take precedence over allow rules
+func (r *Rules) Match(ip net.IP) (rule *Rule, allowed bool) {
+	for _, rule := range r.Deny {
+		if rule.Network.Contains(ip) {
+			return rule, false
+		}
+	}
+	for _, rule := range r.Allow {
+		if rule.Network.Contains(ip) {
+			return rule, true
+		}
+	}
+	return nil, len(r.Allow) == 0
+}
+
+// merge returns the union of two sets of rules
+func (r *Rules) merge(other *Rules) *Rules {
+	if other == nil {
+		return r
+	}
+	return &Rules{
+		Allow: append(append([]*Rule{}, r.Allow...), other.Allow...),
+		Deny:  append(append([]*Rule{}, r.Deny...), other.Deny...),
+	}
+}
+
+// RuleFile is the format of a rule file
+type RuleFile struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// FileRules are rules loaded from a JSON file, which is reloaded when it changes
+type FileRules struct {
+	*watch.File
+}
+
+// NewFileRules creates new file rules and loads the file
+func NewFileRules(path string) (*FileRules, error) {
+	file, err := watch.NewFile(path, "rule file", parseRuleFile)
+	if err != nil {
+		return nil, err
+	}
+	return &FileRules{File: file}, nil
+}
+
+// parseRuleFile parses a rule file into rules
+func parseRuleFile(data []byte) (interface{}, error) {
+	file := RuleFile{}
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(file.Allow, file.Deny)
+}
+
+// Rules returns the rules, reloading the file when it has changed
+func (f *FileRules) Rules() *Rules {
+	return f.Value().(*Rules)
+}
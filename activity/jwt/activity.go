@@ -1,12 +1,13 @@
 package jwt
 
 import (
-	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/coerce"
 	"github.com/project-flogo/core/data/metadata"
 )
 
@@ -28,11 +29,53 @@ func New(ctx activity.InitContext) (activity.Activity, error) {
 	logger := ctx.Logger()
 	logger.Debugf("Setting: %b", settings)
 
-	act := &Activity{}
+	act := &Activity{
+		scheme: settings.Scheme,
+		cookie: settings.Cookie,
+		query:  settings.Query,
+		leeway: time.Duration(settings.Leeway) * time.Second,
+	}
+	if act.scheme == "" {
+		act.scheme = DefaultScheme
+	}
+	act.requiredClaims, err = toStrings(settings.RequiredClaims)
+	if err != nil {
+		return nil, err
+	}
+	algorithms, err := toStrings(settings.Algorithms)
+	if err != nil {
+		return nil, err
+	}
+	act.algorithms, err = parseAlgorithms(algorithms)
+	if err != nil {
+		return nil, err
+	}
+	act.rules, err = ParseRules(settings.Rules)
+	if err != nil {
+		return nil, err
+	}
+	if settings.JWKS != "" {
+		act.jwks, err = NewJWKS(settings.JWKS,
+			time.Duration(settings.JWKSRefresh)*time.Second,
+			time.Duration(settings.JWKSMinRefresh)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return act, nil
 }
 
-type Activity struct{}
+// Activity is a JWT validator
+type Activity struct {
+	jwks           *JWKS
+	rules          []*Rule
+	scheme         string
+	cookie         string
+	query          string
+	leeway         time.Duration
+	requiredClaims []string
+	algorithms     []string
+}
 
 // Metadata return the metadata for the activity
 func (a *Activity) Metadata() *activity.Metadata {
@@ -46,11 +89,19 @@ func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
 	if err != nil {
 		return false, err
 	}
-	if len(input.Token) < 8 {
-		return true, errors.New("token should be longer")
+	raw, err := a.extractToken(&input)
+	if err != nil {
+		err = ctx.SetOutputObject(&Output{ValidationMessage: err.Error()})
+		if err != nil {
+			return false, err
+		}
+		return true, nil
 	}
-	input.Token = input.Token[7:]
-	token, err := jwt.Parse(input.Token, func(token *jwt.Token) (interface{}, error) {
+	parser := jwt.Parser{ValidMethods: a.algorithms, SkipClaimsValidation: true}
+	token, err := parser.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		if token.Method == jwt.SigningMethodNone {
+			return nil, jwt.NewValidationError(ErrorNoneAlgorithm.Error(), jwt.ValidationErrorSignatureInvalid)
+		}
 		// Make sure signing alg matches what we expect
 		switch strings.ToLower(input.SigningMethod) {
 		case "hmac":
@@ -69,6 +120,10 @@ func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
 			if _, ok := token.Method.(*jwt.SigningMethodRSAPSS); !ok {
 				return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 			}
+		case "eddsa":
+			if _, ok := token.Method.(*SigningMethodEd25519); !ok {
+				return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+			}
 		case "":
 		// Just continue
 		default:
@@ -89,23 +144,32 @@ func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
 			return nil, jwt.NewValidationError("unable to parse claims", jwt.ValidationErrorClaimsInvalid)
 		}
 
-		return []byte(input.Key), nil
+		if a.jwks != nil {
+			kid, _ := token.Header["kid"].(string)
+			return a.jwks.Key(kid, token.Method)
+		}
+		return ParseVerificationKey(token.Method, input.Key)
 	})
+	if err == nil {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			err = a.verifyClaims(claims, time.Now())
+		}
+	}
 	output := Output{}
-	if token != nil && token.Valid {
+	if err == nil && token != nil && token.Valid {
 		output.Valid = true
 		output.Token = ParsedToken{Signature: token.Signature, SigningMethod: token.Method.Alg(), Header: token.Header}
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			result := make(map[string]interface{})
-			for key, value := range claims {
-				switch key {
-				case "id":
-					result[key] = value.(string)
-				default:
-					//none
-				}
+			output.Token.Claims, err = typedClaims(claims)
+		}
+		if err != nil {
+			output.Valid = false
+			output.ValidationMessage = err.Error()
+		} else {
+			output.Authorized, output.Rules, err = evaluateRules(a.rules, output.Token.Claims)
+			if err != nil {
+				output.ValidationMessage = err.Error()
 			}
-			output.Token.Claims = result
 		}
 	} else if ve, ok := err.(*jwt.ValidationError); ok {
 		output.Valid = false
@@ -122,3 +186,15 @@ func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
 	}
 	return true, nil
 }
+
+func toStrings(values []interface{}) ([]string, error) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		s, err := coerce.ToString(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
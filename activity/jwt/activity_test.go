@@ -1,8 +1,26 @@
 package jwt
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/project-flogo/core/activity"
 	"github.com/project-flogo/core/data"
 	"github.com/project-flogo/core/data/mapper"
@@ -128,3 +146,594 @@ func TestJWT(t *testing.T) {
 	}
 	execute("reset", inputValues, nil)
 }
+
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	return &testKeys{
+		rsa:     rsaKey,
+		ecdsa:   ecdsaKey,
+		ed25519: ed25519Key,
+	}
+}
+
+func (k *testKeys) jwks(prefix string) []byte {
+	encode := base64.RawURLEncoding.EncodeToString
+	set := JSONWebKeySet{
+		Keys: []JSONWebKey{
+			{
+				KeyType:   "RSA",
+				KeyID:     prefix + "rsa",
+				Use:       "sig",
+				Algorithm: "RS256",
+				N:         encode(k.rsa.N.Bytes()),
+				E:         encode(big.NewInt(int64(k.rsa.E)).Bytes()),
+			},
+			{
+				KeyType: "EC",
+				KeyID:   prefix + "ecdsa",
+				Curve:   "P-256",
+				X:       encode(k.ecdsa.X.Bytes()),
+				Y:       encode(k.ecdsa.Y.Bytes()),
+			},
+			{
+				KeyType: "OKP",
+				KeyID:   prefix + "ed25519",
+				Curve:   "Ed25519",
+				X:       encode(k.ed25519.Public().(ed25519.PublicKey)),
+			},
+			{
+				KeyType: "oct",
+				KeyID:   prefix + "hmac",
+				K:       encode([]byte("secret")),
+			},
+			{
+				KeyType: "RSA",
+				KeyID:   prefix + "encryption",
+				Use:     "enc",
+			},
+			{
+				KeyType: "RSA",
+				KeyID:   prefix + "invalid",
+				N:       "!",
+			},
+			{
+				KeyType: "unknown",
+				KeyID:   prefix + "unknown",
+			},
+		},
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	return signClaims(t, method, kid, key, jwt.MapClaims{
+		"iss": "Mashling",
+		"sub": "tempuser@mail.com",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+func signClaims(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	assert.Nil(t, err)
+	return "Bearer " + signed
+}
+
+func validate(t *testing.T, act activity.Activity, values map[string]interface{}) map[string]interface{} {
+	ctx := newActivityContext(values)
+	_, err := act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func TestJWTJWKSFile(t *testing.T) {
+	keys := newTestKeys(t)
+	dir, err := ioutil.TempDir("", "jwks")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "jwks.json")
+	err = ioutil.WriteFile(file, keys.jwks(""), 0644)
+	assert.Nil(t, err)
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"jwks": file,
+	}))
+	assert.Nil(t, err)
+
+	tokens := map[string]string{
+		"rsa":   sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa),
+		"ecdsa": sign(t, jwt.SigningMethodES256, "ecdsa", keys.ecdsa),
+		"eddsa": sign(t, SigningMethodEdDSA, "ed25519", keys.ed25519),
+		"hmac":  sign(t, jwt.SigningMethodHS256, "hmac", []byte("secret")),
+	}
+	for method, token := range tokens {
+		output := validate(t, act, map[string]interface{}{
+			"token":         token,
+			"signingMethod": method,
+			"iss":           "Mashling",
+		})
+		assert.True(t, output["valid"].(bool), method)
+	}
+
+	output := validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodRS256, "unknown", keys.rsa),
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorKeyNotFound.Error(), output["validationMessage"])
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodRS256, "invalid", keys.rsa),
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorKeyNotFound.Error(), output["validationMessage"], "invalid keys should be skipped")
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodRS384, "rsa", keys.rsa),
+	})
+	assert.False(t, output["valid"].(bool), "alg of the key should be enforced")
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodHS256, "ecdsa", []byte("secret")),
+	})
+	assert.False(t, output["valid"].(bool), "key type should be enforced")
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodHS256, "ed25519", []byte(keys.ed25519.Public().(ed25519.PublicKey))),
+	})
+	assert.False(t, output["valid"].(bool), "a public key should never be an HMAC secret")
+	assert.Contains(t, output["validationMessage"], "Unexpected signing method for key")
+
+	output = validate(t, act, map[string]interface{}{
+		"token":         tokens["rsa"],
+		"signingMethod": "ecdsa",
+	})
+	assert.False(t, output["valid"].(bool), "signing method should be enforced")
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"jwks": filepath.Join(dir, "missing.json"),
+	}))
+	assert.NotNil(t, err)
+}
+
+func TestJWTJWKSRotation(t *testing.T) {
+	keys, rotated := newTestKeys(t), newTestKeys(t)
+	lock, jwks, requests := sync.Mutex{}, keys.jwks("a-"), 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests++
+		w.Write(jwks)
+	}))
+	defer server.Close()
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"jwks":           server.URL,
+		"jwksRefresh":    3600,
+		"jwksMinRefresh": 3600,
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, requests)
+
+	output := validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodES256, "a-ecdsa", keys.ecdsa),
+	})
+	assert.True(t, output["valid"].(bool))
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodHS256, "a-hmac", []byte("secret")),
+	})
+	assert.False(t, output["valid"].(bool), "symmetric keys should not be accepted from a URL")
+	assert.Equal(t, ErrorKeyNotFound.Error(), output["validationMessage"])
+
+	lock.Lock()
+	jwks = rotated.jwks("b-")
+	lock.Unlock()
+	token := sign(t, jwt.SigningMethodES256, "b-ecdsa", rotated.ecdsa)
+
+	output = validate(t, act, map[string]interface{}{
+		"token": token,
+	})
+	assert.False(t, output["valid"].(bool), "keys should not be refreshed before the minimum refresh interval")
+	assert.Equal(t, 1, requests)
+
+	cache := act.(*Activity).jwks
+	cache.Lock()
+	cache.fetched = time.Now().Add(-2 * time.Hour)
+	cache.Unlock()
+	output = validate(t, act, map[string]interface{}{
+		"token": token,
+	})
+	assert.True(t, output["valid"].(bool), "keys should be refreshed")
+	assert.Equal(t, 2, requests)
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodES256, "a-ecdsa", keys.ecdsa),
+	})
+	assert.False(t, output["valid"].(bool), "rotated key should be removed")
+}
+
+func TestJWTJWKSConcurrentRefresh(t *testing.T) {
+	keys, rotated := newTestKeys(t), newTestKeys(t)
+	lock, jwks, requests := sync.Mutex{}, keys.jwks("a-"), 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests++
+		data := jwks
+		lock.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"jwks":           server.URL,
+		"jwksRefresh":    3600,
+		"jwksMinRefresh": 3600,
+	}))
+	assert.Nil(t, err)
+
+	lock.Lock()
+	jwks = rotated.jwks("b-")
+	lock.Unlock()
+	cache := act.(*Activity).jwks
+	cache.Lock()
+	cache.fetched = time.Now().Add(-2 * time.Hour)
+	cache.Unlock()
+
+	token := sign(t, jwt.SigningMethodES256, "b-ecdsa", rotated.ecdsa)
+	valid, wait := make(chan bool, 16), sync.WaitGroup{}
+	for i := 0; i < cap(valid); i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			output := validate(t, act, map[string]interface{}{
+				"token": token,
+			})
+			valid <- output["valid"].(bool)
+		}()
+	}
+	wait.Wait()
+	close(valid)
+	for v := range valid {
+		assert.True(t, v, "requests should wait for the keys being loaded")
+	}
+	assert.Equal(t, 2, requests, "a stale key set should be loaded once")
+}
+
+func TestJWTPublicKey(t *testing.T) {
+	keys := newTestKeys(t)
+	act, err := New(newInitContext(nil))
+	assert.Nil(t, err)
+
+	encode := func(key interface{}) string {
+		data, err := x509.MarshalPKIXPublicKey(key)
+		assert.Nil(t, err)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: data}))
+	}
+
+	output := validate(t, act, map[string]interface{}{
+		"token":         sign(t, jwt.SigningMethodRS256, "", keys.rsa),
+		"signingMethod": "rsa",
+		"key":           encode(&keys.rsa.PublicKey),
+	})
+	assert.True(t, output["valid"].(bool))
+
+	output = validate(t, act, map[string]interface{}{
+		"token":         sign(t, jwt.SigningMethodPS256, "", keys.rsa),
+		"signingMethod": "rsapss",
+		"key":           encode(&keys.rsa.PublicKey),
+	})
+	assert.True(t, output["valid"].(bool))
+
+	output = validate(t, act, map[string]interface{}{
+		"token":         sign(t, jwt.SigningMethodES256, "", keys.ecdsa),
+		"signingMethod": "ecdsa",
+		"key":           encode(&keys.ecdsa.PublicKey),
+	})
+	assert.True(t, output["valid"].(bool))
+
+	output = validate(t, act, map[string]interface{}{
+		"token":         sign(t, SigningMethodEdDSA, "", keys.ed25519),
+		"signingMethod": "eddsa",
+		"key":           encode(keys.ed25519.Public()),
+	})
+	assert.True(t, output["valid"].(bool))
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodES256, "", keys.ecdsa),
+		"key":   encode(&keys.rsa.PublicKey),
+	})
+	assert.False(t, output["valid"].(bool))
+
+	// a public key must never be used as an HMAC secret, whatever the alg of the token
+	for _, public := range []string{encode(&keys.rsa.PublicKey), encode(&keys.ecdsa.PublicKey), encode(keys.ed25519.Public())} {
+		for _, method := range []jwt.SigningMethod{jwt.SigningMethodHS256, jwt.SigningMethodHS512} {
+			output = validate(t, act, map[string]interface{}{
+				"token": sign(t, method, "", []byte(public)),
+				"key":   public,
+			})
+			assert.False(t, output["valid"].(bool), "algorithm confusion with %s", method.Alg())
+		}
+	}
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodHS256, "", []byte("secret")),
+		"key":   "secret",
+	})
+	assert.True(t, output["valid"].(bool))
+
+	output = validate(t, act, map[string]interface{}{
+		"token": sign(t, jwt.SigningMethodRS256, "", keys.rsa),
+		"key":   "secret",
+	})
+	assert.False(t, output["valid"].(bool), "an HMAC secret should not verify RSA tokens")
+}
+
+func TestJWTClaims(t *testing.T) {
+	act, err := New(newInitContext(nil))
+	assert.Nil(t, err)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	output := validate(t, act, map[string]interface{}{
+		"key": "secret",
+		"token": signClaims(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+			"iss":    "Mashling",
+			"aud":    "www.mashling.io",
+			"exp":    exp,
+			"id":     "1",
+			"scope":  "read write",
+			"tenant": "acme",
+			"realm":  map[string]interface{}{"roles": []string{"admin"}},
+		}),
+	})
+	assert.True(t, output["valid"].(bool))
+	assert.True(t, output["authorized"].(bool))
+	claims := output["token"].(ParsedToken).Claims
+	assert.Equal(t, "Mashling", claims["iss"])
+	assert.Equal(t, []string{"www.mashling.io"}, claims["aud"])
+	assert.Equal(t, exp, claims["exp"])
+	assert.Equal(t, "1", claims["id"])
+	assert.Equal(t, "read write", claims["scope"])
+	assert.Equal(t, "acme", claims["tenant"])
+	assert.Equal(t, map[string]interface{}{"roles": []interface{}{"admin"}}, claims["realm"])
+}
+
+func TestJWTRules(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"name":     "write",
+				"claim":    "scope",
+				"contains": "write",
+			},
+			map[string]interface{}{
+				"name":  "admin",
+				"claim": "realm.roles",
+				"in":    []interface{}{"admin", "owner"},
+			},
+			map[string]interface{}{
+				"name":       "tenant",
+				"expression": "$.claims.tenant == \"acme\"",
+			},
+		},
+	}))
+	assert.Nil(t, err)
+
+	validateClaims := func(claims jwt.MapClaims) map[string]interface{} {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		return validate(t, act, map[string]interface{}{
+			"key":   "secret",
+			"token": signClaims(t, jwt.SigningMethodHS256, "", []byte("secret"), claims),
+		})
+	}
+
+	output := validateClaims(jwt.MapClaims{
+		"scope":  "read write",
+		"tenant": "acme",
+		"realm":  map[string]interface{}{"roles": []string{"user", "owner"}},
+	})
+	assert.True(t, output["valid"].(bool))
+	assert.True(t, output["authorized"].(bool))
+	assert.Equal(t, map[string]bool{"write": true, "admin": true, "tenant": true}, output["rules"])
+
+	output = validateClaims(jwt.MapClaims{
+		"scope":  "read",
+		"tenant": "other",
+		"realm":  map[string]interface{}{"roles": []string{"admin"}},
+	})
+	assert.True(t, output["valid"].(bool))
+	assert.False(t, output["authorized"].(bool))
+	assert.Equal(t, map[string]bool{"write": false, "admin": true, "tenant": false}, output["rules"])
+	assert.Equal(t, "rule 'write' failed", output["validationMessage"])
+
+	output = validateClaims(jwt.MapClaims{
+		"scope":  []string{"write"},
+		"tenant": "acme",
+	})
+	assert.False(t, output["authorized"].(bool))
+	assert.Equal(t, map[string]bool{"write": true, "admin": false, "tenant": true}, output["rules"])
+
+	output = validate(t, act, map[string]interface{}{
+		"key":   "secret",
+		"token": signClaims(t, jwt.SigningMethodHS256, "", []byte("other"), jwt.MapClaims{}),
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.False(t, output["authorized"].(bool))
+
+	invalid := [][]interface{}{
+		{map[string]interface{}{"claim": "scope"}},
+		{map[string]interface{}{"contains": "write"}},
+		{map[string]interface{}{"claim": "scope", "contains": "write", "expression": "true"}},
+		{map[string]interface{}{"claim": "scope", "equals": "write"}},
+		{"rule"},
+	}
+	for _, rules := range invalid {
+		_, err = New(newInitContext(map[string]interface{}{
+			"rules": rules,
+		}))
+		assert.NotNil(t, err, rules)
+	}
+}
+
+func TestJWTExtraction(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"cookie": "access_token",
+		"query":  "access_token",
+	}))
+	assert.Nil(t, err)
+
+	token := sign(t, jwt.SigningMethodHS256, "", []byte("secret"))
+	raw := token[len("Bearer "):]
+	inputs := []map[string]interface{}{
+		{"token": token},
+		{"token": "bearer " + raw},
+		{"token": "BEARER  " + raw},
+		{"token": raw},
+		{"headers": map[string]string{"cookie": "session=1; access_token=" + raw}},
+		{"queryParams": map[string]string{"access_token": raw}},
+	}
+	for _, input := range inputs {
+		input["key"] = "secret"
+		output := validate(t, act, input)
+		assert.True(t, output["valid"].(bool), input)
+	}
+
+	output := validate(t, act, map[string]interface{}{
+		"key":   "secret",
+		"token": "Basic " + raw,
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, "invalid authorization scheme, expected Bearer", output["validationMessage"])
+
+	output = validate(t, act, map[string]interface{}{
+		"key":     "secret",
+		"token":   "",
+		"headers": map[string]string{"Cookie": "session=1"},
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorTokenNotFound.Error(), output["validationMessage"])
+
+	act, err = New(newInitContext(map[string]interface{}{
+		"scheme": "JWT",
+	}))
+	assert.Nil(t, err)
+	output = validate(t, act, map[string]interface{}{
+		"key":   "secret",
+		"token": "jwt " + raw,
+	})
+	assert.True(t, output["valid"].(bool))
+}
+
+func TestJWTLeeway(t *testing.T) {
+	act, err := New(newInitContext(nil))
+	assert.Nil(t, err)
+	lenient, err := New(newInitContext(map[string]interface{}{
+		"leeway": 30,
+	}))
+	assert.Nil(t, err)
+
+	now := time.Now()
+	tests := []struct {
+		claims  jwt.MapClaims
+		message string
+	}{
+		{jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}, "Token is expired"},
+		{jwt.MapClaims{"nbf": now.Add(10 * time.Second).Unix()}, "Token is not valid yet"},
+		{jwt.MapClaims{"iat": now.Add(10 * time.Second).Unix()}, "Token used before issued"},
+	}
+	for _, test := range tests {
+		input := map[string]interface{}{
+			"key":   "secret",
+			"token": signClaims(t, jwt.SigningMethodHS256, "", []byte("secret"), test.claims),
+		}
+		output := validate(t, act, input)
+		assert.False(t, output["valid"].(bool), test.message)
+		assert.Equal(t, test.message, output["validationMessage"])
+		output = validate(t, lenient, input)
+		assert.True(t, output["valid"].(bool), test.message)
+	}
+
+	output := validate(t, lenient, map[string]interface{}{
+		"key": "secret",
+		"token": signClaims(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+			"exp": now.Add(-time.Minute).Unix(),
+		}),
+	})
+	assert.False(t, output["valid"].(bool))
+}
+
+func TestJWTRequiredClaims(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"requiredClaims": []interface{}{"exp", "sub"},
+	}))
+	assert.Nil(t, err)
+
+	output := validate(t, act, map[string]interface{}{
+		"key":   "secret",
+		"token": sign(t, jwt.SigningMethodHS256, "", []byte("secret")),
+	})
+	assert.True(t, output["valid"].(bool))
+
+	output = validate(t, act, map[string]interface{}{
+		"key": "secret",
+		"token": signClaims(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}),
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, "missing required claim 'sub'", output["validationMessage"])
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	act, err := New(newInitContext(nil))
+	assert.Nil(t, err)
+	none := signClaims(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, jwt.MapClaims{})
+	output := validate(t, act, map[string]interface{}{
+		"token": none,
+	})
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorNoneAlgorithm.Error(), output["validationMessage"])
+
+	act, err = New(newInitContext(map[string]interface{}{
+		"algorithms": []interface{}{"HS512"},
+	}))
+	assert.Nil(t, err)
+	output = validate(t, act, map[string]interface{}{
+		"key":   "secret",
+		"token": sign(t, jwt.SigningMethodHS512, "", []byte("secret")),
+	})
+	assert.True(t, output["valid"].(bool))
+	output = validate(t, act, map[string]interface{}{
+		"key":   "secret",
+		"token": sign(t, jwt.SigningMethodHS256, "", []byte("secret")),
+	})
+	assert.False(t, output["valid"].(bool))
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"algorithms": []interface{}{"HS256", "none"},
+	}))
+	assert.Equal(t, ErrorNoneAlgorithm, err)
+	_, err = New(newInitContext(map[string]interface{}{
+		"algorithms": []interface{}{"XS256"},
+	}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,291 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	logger "github.com/project-flogo/core/support/log"
+)
+
+const (
+	// DefaultJWKSRefresh is the default number of seconds between refreshes of a JWKS
+	DefaultJWKSRefresh = 3600
+	// DefaultJWKSMinRefresh is the default minimum number of seconds between refreshes of a JWKS
+	DefaultJWKSMinRefresh = 60
+)
+
+var (
+	// ErrorKeyNotFound happens when the key of a token isn't in the JWKS
+	ErrorKeyNotFound = errors.New("key not found in JWKS")
+	log              = logger.ChildLogger(logger.RootLogger(), "jwks")
+)
+
+// JSONWebKey is a JSON web key as defined in RFC 7517
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	K         string `json:"k"`
+}
+
+// PublicKey parses the public key of the JSON web key
+func (k *JSONWebKey) PublicKey() (interface{}, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.KeyType {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || exponent.Int64() < 3 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(exponent.Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Curve)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("invalid EC public key")
+		}
+		return key, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve: %s", k.Curve)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, ErrorNotEd25519PublicKey
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return decode(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", k.KeyType)
+}
+
+// JSONWebKeySet is a JSON web key set as defined in RFC 7517
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type verificationKey struct {
+	algorithm string
+	key       interface{}
+}
+
+// JWKS is a cached JSON web key set loaded from a file or URL
+type JWKS struct {
+	source              string
+	refresh, minRefresh time.Duration
+	client              *http.Client
+
+	sync.RWMutex
+	keys    map[string]verificationKey
+	fetched time.Time
+	loading chan struct{}
+}
+
+// NewJWKS creates a new JWKS and loads the keys from source
+func NewJWKS(source string, refresh, minRefresh time.Duration) (*JWKS, error) {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh * time.Second
+	}
+	if minRefresh <= 0 {
+		minRefresh = DefaultJWKSMinRefresh * time.Second
+	}
+	jwks := &JWKS{
+		source:     source,
+		refresh:    refresh,
+		minRefresh: minRefresh,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+	err := jwks.Load()
+	if err != nil {
+		return nil, err
+	}
+	return jwks, nil
+}
+
+// Load loads the keys from the source of the JWKS. Keys which can't be parsed are skipped,
+// and symmetric keys are only accepted from local files
+func (j *JWKS) Load() error {
+	data, err := j.read()
+	j.Lock()
+	defer j.Unlock()
+	j.fetched = time.Now()
+	if err != nil {
+		return err
+	}
+
+	set := JSONWebKeySet{}
+	err = json.Unmarshal(data, &set)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for i := range set.Keys {
+		key := &set.Keys[i]
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.KeyType == "oct" && j.remote() {
+			log.Warnf("Skipping symmetric key '%s' of remote JWKS %s", key.KeyID, j.source)
+			continue
+		}
+		parsed, err := key.PublicKey()
+		if err != nil {
+			log.Warnf("Skipping invalid key '%s' of JWKS %s: %v", key.KeyID, j.source, err)
+			continue
+		}
+		keys[key.KeyID] = verificationKey{
+			algorithm: key.Algorithm,
+			key:       parsed,
+		}
+	}
+	j.keys = keys
+	return nil
+}
+
+// remote returns if the JWKS is loaded from a URL
+func (j *JWKS) remote() bool {
+	return strings.HasPrefix(j.source, "http://") || strings.HasPrefix(j.source, "https://")
+}
+
+func (j *JWKS) read() ([]byte, error) {
+	if j.remote() {
+		response, err := j.client.Get(j.source)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unable to get JWKS: %s", response.Status)
+		}
+		return ioutil.ReadAll(response.Body)
+	}
+	return ioutil.ReadFile(strings.TrimPrefix(j.source, "file://"))
+}
+
+// Key returns the key with the given kid for verifying a token signed with method.
+// The keys are reloaded when they are older than the refresh interval, or when the kid
+// is unknown and they are older than the minimum refresh interval
+func (j *JWKS) Key(kid string, method jwt.SigningMethod) (interface{}, error) {
+	j.RLock()
+	key, ok := j.lookup(kid)
+	fetched := j.fetched
+	j.RUnlock()
+
+	age := time.Since(fetched)
+	if age > j.refresh || (!ok && age > j.minRefresh) {
+		err := j.reload(fetched, !ok)
+		if err != nil && !ok {
+			return nil, err
+		}
+		j.RLock()
+		key, ok = j.lookup(kid)
+		j.RUnlock()
+	}
+	if !ok {
+		return nil, ErrorKeyNotFound
+	}
+
+	if key.algorithm != "" && key.algorithm != method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method for key: %v", method.Alg())
+	}
+	err := CheckKeyMethod(method, key.key)
+	if err != nil {
+		return nil, err
+	}
+	return key.key, nil
+}
+
+// reload loads the keys unless they have been loaded since fetched, or are being loaded by
+// another request. Requests which have no key wait for the keys being loaded
+func (j *JWKS) reload(fetched time.Time, wait bool) error {
+	j.Lock()
+	if !j.fetched.Equal(fetched) {
+		j.Unlock()
+		return nil
+	}
+	if loading := j.loading; loading != nil {
+		j.Unlock()
+		if wait {
+			<-loading
+		}
+		return nil
+	}
+	loading := make(chan struct{})
+	j.loading = loading
+	j.Unlock()
+
+	err := j.Load()
+	j.Lock()
+	j.loading = nil
+	j.Unlock()
+	close(loading)
+	return err
+}
+
+func (j *JWKS) lookup(kid string) (verificationKey, bool) {
+	key, ok := j.keys[kid]
+	if !ok && kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	return key, ok
+}
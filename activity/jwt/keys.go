@@ -0,0 +1,179 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	// ErrorNotEd25519PublicKey happens when a key is not a valid Ed25519 public key
+	ErrorNotEd25519PublicKey = errors.New("key is not a valid Ed25519 public key")
+	// ErrorNotEd25519PrivateKey happens when a key is not a valid Ed25519 private key
+	ErrorNotEd25519PrivateKey = errors.New("key is not a valid Ed25519 private key")
+	// SigningMethodEdDSA is the Ed25519 signing method
+	SigningMethodEdDSA = &SigningMethodEd25519{}
+)
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// SigningMethodEd25519 implements the EdDSA signing method with Ed25519 keys
+type SigningMethodEd25519 struct{}
+
+// Alg returns the alg identifier for the signing method
+func (m *SigningMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+// Verify verifies the signature of signingString with an ed25519.PublicKey
+func (m *SigningMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Sign signs signingString with an ed25519.PrivateKey
+func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+// ParseEdPublicKeyFromPEM parses a PEM encoded PKIX Ed25519 public key
+func ParseEdPublicKeyFromPEM(key []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrorNotEd25519PublicKey
+	}
+	return publicKey, nil
+}
+
+// ParsePublicKeyFromPEM parses a PEM encoded RSA, ECDSA or Ed25519 public key or certificate
+func ParsePublicKeyFromPEM(key []byte) (interface{}, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+	if block.Type == "CERTIFICATE" {
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return certificate.PublicKey, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		if rsaKey, rsaErr := x509.ParsePKCS1PublicKey(block.Bytes); rsaErr == nil {
+			return rsaKey, nil
+		}
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// ParseVerificationKey parses a static key for verifying tokens signed with method. The kind of
+// key decides the allowed signing methods, never the token: a PEM encoded public key is only used
+// for the signing methods of its type, and any other key is an HMAC secret
+func ParseVerificationKey(method jwt.SigningMethod, key string) (interface{}, error) {
+	var parsed interface{} = []byte(key)
+	if strings.Contains(key, "-----BEGIN") {
+		var err error
+		parsed, err = ParsePublicKeyFromPEM([]byte(key))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := CheckKeyMethod(method, parsed)
+	if err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// CheckKeyMethod checks that a verification key can be used with a signing method, so that
+// a public key is never used as an HMAC secret
+func CheckKeyMethod(method jwt.SigningMethod, key interface{}) error {
+	var ok bool
+	switch key.(type) {
+	case []byte:
+		_, ok = method.(*jwt.SigningMethodHMAC)
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			ok = true
+		}
+	case *ecdsa.PublicKey:
+		_, ok = method.(*jwt.SigningMethodECDSA)
+	case ed25519.PublicKey:
+		_, ok = method.(*SigningMethodEd25519)
+	default:
+		return fmt.Errorf("Unsupported key type: %T", key)
+	}
+	if !ok {
+		return fmt.Errorf("Unexpected signing method for key: %v", method.Alg())
+	}
+	return nil
+}
+
+// ParseEdPrivateKeyFromPEM parses a PEM encoded PKCS8 Ed25519 private key
+func ParseEdPrivateKeyFromPEM(key []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrorNotEd25519PrivateKey
+	}
+	return privateKey, nil
+}
+
+// ParseSigningKey parses a key for signing tokens with method.
+// HMAC keys are used as is, other keys are PEM encoded private keys
+func ParseSigningKey(method jwt.SigningMethod, key string) (interface{}, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return []byte(key), nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return jwt.ParseRSAPrivateKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPrivateKeyFromPEM([]byte(key))
+	case *SigningMethodEd25519:
+		return ParseEdPrivateKeyFromPEM([]byte(key))
+	}
+	return nil, fmt.Errorf("Unsupported signing method: %v", method.Alg())
+}
@@ -4,15 +4,28 @@ import (
 	"github.com/project-flogo/core/data/coerce"
 )
 
-type Settings struct{}
+type Settings struct {
+	JWKS           string        `md:"jwks"`
+	JWKSRefresh    int           `md:"jwksRefresh"`
+	JWKSMinRefresh int           `md:"jwksMinRefresh"`
+	Rules          []interface{} `md:"rules"`
+	Scheme         string        `md:"scheme"`
+	Cookie         string        `md:"cookie"`
+	Query          string        `md:"query"`
+	Leeway         int           `md:"leeway"`
+	RequiredClaims []interface{} `md:"requiredClaims"`
+	Algorithms     []interface{} `md:"algorithms"`
+}
 
 type Input struct {
-	Token         string `md:"token"`
-	Key           string `md:"key"`
-	SigningMethod string `md:"signingMethod"`
-	Issuer        string `md:"iss"`
-	Subject       string `md:"sub"`
-	Audience      string `md:"aud"`
+	Token         string            `md:"token"`
+	Key           string            `md:"key"`
+	SigningMethod string            `md:"signingMethod"`
+	Issuer        string            `md:"iss"`
+	Subject       string            `md:"sub"`
+	Audience      string            `md:"aud"`
+	Headers       map[string]string `md:"headers"`
+	QueryParams   map[string]string `md:"queryParams"`
 }
 
 func (r *Input) FromMap(values map[string]interface{}) error {
@@ -41,6 +54,14 @@ func (r *Input) FromMap(values map[string]interface{}) error {
 	if err != nil {
 		return err
 	}
+	r.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.QueryParams, err = coerce.ToParams(values["queryParams"])
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -52,15 +73,19 @@ func (r *Input) ToMap() map[string]interface{} {
 		"iss":           r.Issuer,
 		"sub":           r.Subject,
 		"aud":           r.Audience,
+		"headers":       r.Headers,
+		"queryParams":   r.QueryParams,
 	}
 }
 
 type Output struct {
-	Valid             bool        `md:"valid"`
-	Token             ParsedToken `md:"token"`
-	ValidationMessage string      `md:"validationMessage"`
-	Error             bool        `md:"error"`
-	ErrorMessage      string      `md:"errorMessage"`
+	Valid             bool            `md:"valid"`
+	Token             ParsedToken     `md:"token"`
+	ValidationMessage string          `md:"validationMessage"`
+	Authorized        bool            `md:"authorized"`
+	Rules             map[string]bool `md:"rules"`
+	Error             bool            `md:"error"`
+	ErrorMessage      string          `md:"errorMessage"`
 }
 
 // ParsedToken is a parsed JWT token.
@@ -83,6 +108,11 @@ func (o *Output) FromMap(values map[string]interface{}) error {
 	}
 	o.Token = token.(ParsedToken)
 	o.ValidationMessage = values["validationMessage"].(string)
+	o.Authorized, err = coerce.ToBool(values["authorized"])
+	if err != nil {
+		return err
+	}
+	o.Rules, _ = values["rules"].(map[string]bool)
 	o.Error = values["error"].(bool)
 	o.ErrorMessage = values["errorMessage"].(string)
 	return nil
@@ -93,6 +123,8 @@ func (o *Output) ToMap() map[string]interface{} {
 		"valid":             o.Valid,
 		"token":             o.Token,
 		"validationMessage": o.ValidationMessage,
+		"authorized":        o.Authorized,
+		"rules":             o.Rules,
 		"error":             o.Error,
 		"errorMessage":      o.ErrorMessage,
 	}
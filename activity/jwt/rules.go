@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/coerce"
+	"github.com/project-flogo/core/data/expression"
+	_ "github.com/project-flogo/core/data/expression/script"
+	"github.com/project-flogo/core/data/resolve"
+)
+
+var expressionFactory = expression.NewFactory(resolve.GetBasicResolver())
+
+// Rule is a claim based authorization rule. A rule checks that a claim contains a value,
+// that a claim has one of a list of values, or that an expression over the claims is true
+type Rule struct {
+	Name       string
+	Claim      string
+	Contains   string
+	In         []string
+	Expression string
+	expr       expression.Expr
+}
+
+// ParseRules parses the rules from the settings
+func ParseRules(values []interface{}) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(values))
+	for i, value := range values {
+		settings, err := coerce.ToObject(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %v", i, err)
+		}
+		rule := &Rule{}
+		for key, setting := range settings {
+			switch key {
+			case "name":
+				rule.Name, err = coerce.ToString(setting)
+			case "claim":
+				rule.Claim, err = coerce.ToString(setting)
+			case "contains":
+				rule.Contains, err = coerce.ToString(setting)
+			case "in":
+				var in []interface{}
+				in, err = coerce.ToArray(setting)
+				for _, value := range in {
+					rule.In = append(rule.In, fmt.Sprintf("%v", value))
+				}
+			case "expression":
+				rule.Expression, err = coerce.ToString(setting)
+			default:
+				err = fmt.Errorf("unknown field '%s'", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %d: %v", i, err)
+			}
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule%d", i)
+		}
+
+		switch {
+		case rule.Expression != "":
+			if rule.Claim != "" {
+				return nil, fmt.Errorf("rule '%s' has both a claim and an expression", rule.Name)
+			}
+			rule.expr, err = expressionFactory.NewExpr(strings.TrimPrefix(rule.Expression, "="))
+			if err != nil {
+				return nil, fmt.Errorf("invalid expression for rule '%s': %v", rule.Name, err)
+			}
+		case rule.Claim == "":
+			return nil, fmt.Errorf("rule '%s' needs a claim or an expression", rule.Name)
+		case rule.Contains == "" && len(rule.In) == 0:
+			return nil, fmt.Errorf("rule '%s' needs contains or in", rule.Name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Evaluate evaluates the rule against the claims of a token
+func (r *Rule) Evaluate(claims map[string]interface{}) (bool, error) {
+	if r.expr != nil {
+		value, err := r.expr.Eval(data.NewSimpleScope(map[string]interface{}{"claims": claims}, nil))
+		if err != nil {
+			return false, err
+		}
+		return coerce.ToBool(value)
+	}
+
+	values, ok := claimValues(claims, r.Claim)
+	if !ok {
+		return false, nil
+	}
+	if r.Contains != "" {
+		found := false
+		for _, value := range values {
+			if value == r.Contains {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	if len(r.In) > 0 {
+		found := false
+	search:
+		for _, value := range values {
+			for _, allowed := range r.In {
+				if value == allowed {
+					found = true
+					break search
+				}
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// claimValues returns the values of a claim as a list of strings. The claim name is a dot
+// separated path, space delimited strings such as scope are split into multiple values
+func claimValues(claims map[string]interface{}, name string) ([]string, bool) {
+	var value interface{} = claims
+	for _, key := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value), true
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			values = append(values, fmt.Sprintf("%v", item))
+		}
+		return values, true
+	case []string:
+		return value, true
+	case nil:
+		return nil, false
+	}
+	return []string{fmt.Sprintf("%v", value)}, true
+}
+
+// evaluateRules evaluates the rules and returns the result of each rule
+func evaluateRules(rules []*Rule, claims map[string]interface{}) (bool, map[string]bool, error) {
+	authorized, results := true, make(map[string]bool, len(rules))
+	var failed error
+	for _, rule := range rules {
+		result, err := rule.Evaluate(claims)
+		if err != nil {
+			result = false
+		}
+		results[rule.Name] = result
+		if !result && failed == nil {
+			if err != nil {
+				failed = fmt.Errorf("rule '%s' failed: %v", rule.Name, err)
+			} else {
+				failed = fmt.Errorf("rule '%s' failed", rule.Name)
+			}
+		}
+		authorized = authorized && result
+	}
+	return authorized, results, failed
+}
+
+// typedClaims returns the claims with the standard claims converted to their types
+func typedClaims(claims map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(claims))
+	for key, value := range claims {
+		var err error
+		switch key {
+		case "iss", "sub", "jti":
+			value, err = coerce.ToString(value)
+		case "exp", "nbf", "iat":
+			value, err = coerce.ToInt64(value)
+		case "aud":
+			switch aud := value.(type) {
+			case string:
+				value = []string{aud}
+			case []interface{}:
+				audience := make([]string, 0, len(aud))
+				for _, item := range aud {
+					s, ok := item.(string)
+					if !ok {
+						return nil, errors.New("invalid aud claim")
+					}
+					audience = append(audience, s)
+				}
+				value = audience
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s claim: %v", key, err)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// DefaultScheme is the default authorization scheme of a token in a header
+	DefaultScheme = "Bearer"
+)
+
+var (
+	// ErrorTokenNotFound happens when no token is found in the request
+	ErrorTokenNotFound = errors.New("token not found")
+	// ErrorNoneAlgorithm happens when a token isn't signed
+	ErrorNoneAlgorithm = errors.New("the 'none' signing method is not allowed")
+)
+
+// extractToken extracts the raw token from the authorization header, a cookie or a query parameter
+func (a *Activity) extractToken(input *Input) (string, error) {
+	if input.Token != "" {
+		token := strings.TrimSpace(input.Token)
+		if len(token) > len(a.scheme) && strings.EqualFold(token[:len(a.scheme)+1], a.scheme+" ") {
+			return strings.TrimSpace(token[len(a.scheme)+1:]), nil
+		}
+		if strings.ContainsAny(token, " \t") {
+			return "", fmt.Errorf("invalid authorization scheme, expected %s", a.scheme)
+		}
+		return token, nil
+	}
+
+	if a.cookie != "" {
+		for key, value := range input.Headers {
+			if !strings.EqualFold(key, "Cookie") {
+				continue
+			}
+			request := http.Request{Header: http.Header{"Cookie": {value}}}
+			if cookie, err := request.Cookie(a.cookie); err == nil && cookie.Value != "" {
+				return cookie.Value, nil
+			}
+		}
+	}
+
+	if a.query != "" {
+		if token := input.QueryParams[a.query]; token != "" {
+			return token, nil
+		}
+	}
+
+	return "", ErrorTokenNotFound
+}
+
+// parseAlgorithms checks the allowed signing algorithms
+func parseAlgorithms(algorithms []string) ([]string, error) {
+	if len(algorithms) == 0 {
+		return nil, nil
+	}
+	for _, algorithm := range algorithms {
+		if strings.EqualFold(algorithm, jwt.SigningMethodNone.Alg()) {
+			return nil, ErrorNoneAlgorithm
+		}
+		if jwt.GetSigningMethod(algorithm) == nil {
+			return nil, fmt.Errorf("unknown signing algorithm: %s", algorithm)
+		}
+	}
+	return algorithms, nil
+}
+
+// verifyClaims verifies the time based claims with a leeway for clock skew and the presence of the required claims
+func (a *Activity) verifyClaims(claims jwt.MapClaims, now time.Time) error {
+	verr := &jwt.ValidationError{}
+	if !claims.VerifyExpiresAt(now.Add(-a.leeway).Unix(), false) {
+		verr.Inner = errors.New("Token is expired")
+		verr.Errors |= jwt.ValidationErrorExpired
+	}
+	if !claims.VerifyIssuedAt(now.Add(a.leeway).Unix(), false) {
+		verr.Inner = errors.New("Token used before issued")
+		verr.Errors |= jwt.ValidationErrorIssuedAt
+	}
+	if !claims.VerifyNotBefore(now.Add(a.leeway).Unix(), false) {
+		verr.Inner = errors.New("Token is not valid yet")
+		verr.Errors |= jwt.ValidationErrorNotValidYet
+	}
+	for _, claim := range a.requiredClaims {
+		if value, ok := claims[claim]; !ok || value == nil {
+			verr.Inner = fmt.Errorf("missing required claim '%s'", claim)
+			verr.Errors |= jwt.ValidationErrorClaimsInvalid
+			break
+		}
+	}
+	if verr.Errors != 0 {
+		return verr
+	}
+	return nil
+}
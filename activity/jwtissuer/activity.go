@@ -0,0 +1,154 @@
+package jwtissuer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+	validator "github.com/project-flogo/microgateway/activity/jwt"
+)
+
+const (
+	// DefaultTTL is the default number of seconds an issued token is valid
+	DefaultTTL = 300
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new JWT issuer
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	method := jwt.GetSigningMethod(settings.SigningMethod)
+	if method == nil || method == jwt.SigningMethodNone {
+		return nil, fmt.Errorf("invalid signing method: %s", settings.SigningMethod)
+	}
+
+	key, err := loadKey(&settings)
+	if err != nil {
+		return nil, err
+	}
+	signingKey, err := validator.ParseSigningKey(method, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(settings.TTL) * time.Second
+	if ttl <= 0 {
+		ttl = DefaultTTL * time.Second
+	}
+
+	act := &Activity{
+		method:   method,
+		key:      signingKey,
+		kid:      settings.KeyID,
+		issuer:   settings.Issuer,
+		audience: settings.Audience,
+		ttl:      ttl,
+	}
+	return act, nil
+}
+
+// loadKey loads the key from the settings, a file or an environment variable
+func loadKey(settings *Settings) (string, error) {
+	switch {
+	case settings.Key != "":
+		return settings.Key, nil
+	case settings.KeyFile != "":
+		data, err := ioutil.ReadFile(settings.KeyFile)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case settings.KeyEnv != "":
+		key, ok := os.LookupEnv(settings.KeyEnv)
+		if !ok || key == "" {
+			return "", fmt.Errorf("environment variable %s is not set", settings.KeyEnv)
+		}
+		return key, nil
+	}
+	return "", errors.New("key, keyFile or keyEnv is required")
+}
+
+// Activity is a JWT issuer
+type Activity struct {
+	method   jwt.SigningMethod
+	key      interface{}
+	kid      string
+	issuer   string
+	audience string
+	ttl      time.Duration
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	claims := make(jwt.MapClaims, len(input.Claims)+6)
+	for key, value := range input.Claims {
+		claims[key] = value
+	}
+	if a.issuer != "" {
+		claims["iss"] = a.issuer
+	}
+	if input.Subject != "" {
+		claims["sub"] = input.Subject
+	}
+	if input.Audience != "" {
+		claims["aud"] = input.Audience
+	} else if a.audience != "" {
+		claims["aud"] = a.audience
+	}
+	now := time.Now()
+	expiresAt := now.Add(a.ttl).Unix()
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = expiresAt
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	if err != nil {
+		return false, err
+	}
+	claims["jti"] = hex.EncodeToString(id)
+
+	token := jwt.NewWithClaims(a.method, claims)
+	if a.kid != "" {
+		token.Header["kid"] = a.kid
+	}
+	signed, err := token.SignedString(a.key)
+	if err != nil {
+		return false, err
+	}
+
+	err = ctx.SetOutputObject(&Output{Token: signed, ExpiresAt: expiresAt})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
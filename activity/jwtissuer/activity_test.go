@@ -0,0 +1,242 @@
+package jwtissuer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func issue(t *testing.T, settings, input map[string]interface{}) map[string]interface{} {
+	act, err := New(newInitContext(settings))
+	assert.Nil(t, err)
+	ctx := newActivityContext(input)
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func parse(t *testing.T, token string, key interface{}) jwt.MapClaims {
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		return key, nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, parsed.Valid)
+	return parsed.Claims.(jwt.MapClaims)
+}
+
+func TestIssuerHMAC(t *testing.T) {
+	os.Setenv("JWT_ISSUER_TEST_KEY", "secret")
+	defer os.Unsetenv("JWT_ISSUER_TEST_KEY")
+
+	output := issue(t, map[string]interface{}{
+		"signingMethod": "HS256",
+		"keyEnv":        "JWT_ISSUER_TEST_KEY",
+		"iss":           "Mashling",
+		"aud":           "internal",
+		"ttl":           60,
+	}, map[string]interface{}{
+		"sub": "tempuser@mail.com",
+		"claims": map[string]interface{}{
+			"scope": "read",
+			"iss":   "spoofed",
+		},
+	})
+	claims := parse(t, output["token"].(string), []byte("secret"))
+	assert.Equal(t, "Mashling", claims["iss"])
+	assert.Equal(t, "internal", claims["aud"])
+	assert.Equal(t, "tempuser@mail.com", claims["sub"])
+	assert.Equal(t, "read", claims["scope"])
+	assert.NotEmpty(t, claims["jti"])
+	expiresAt := output["expiresAt"].(int64)
+	assert.Equal(t, float64(expiresAt), claims["exp"])
+	assert.InDelta(t, time.Now().Add(time.Minute).Unix(), expiresAt, 2)
+
+	output = issue(t, map[string]interface{}{
+		"signingMethod": "HS512",
+		"key":           "secret",
+	}, map[string]interface{}{
+		"aud": "other",
+	})
+	claims = parse(t, output["token"].(string), []byte("secret"))
+	assert.Equal(t, "other", claims["aud"])
+	assert.InDelta(t, time.Now().Add(DefaultTTL*time.Second).Unix(), output["expiresAt"], 2)
+}
+
+func TestIssuerKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwtissuer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	ed25519Public, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	write := func(name, kind string, data []byte) string {
+		file := filepath.Join(dir, name)
+		err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: data}), 0600)
+		assert.Nil(t, err)
+		return file
+	}
+	ecdsaData, err := x509.MarshalECPrivateKey(ecdsaKey)
+	assert.Nil(t, err)
+	ed25519Data, err := x509.MarshalPKCS8PrivateKey(ed25519Key)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		method    string
+		file      string
+		publicKey interface{}
+	}{
+		{"RS256", write("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), &rsaKey.PublicKey},
+		{"PS384", write("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), &rsaKey.PublicKey},
+		{"ES256", write("ecdsa.pem", "EC PRIVATE KEY", ecdsaData), &ecdsaKey.PublicKey},
+		{"EdDSA", write("ed25519.pem", "PRIVATE KEY", ed25519Data), ed25519Public},
+	}
+	for _, test := range tests {
+		output := issue(t, map[string]interface{}{
+			"signingMethod": test.method,
+			"keyFile":       test.file,
+			"kid":           "key-1",
+		}, nil)
+		token, err := jwt.Parse(output["token"].(string), func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, test.method, token.Method.Alg())
+			assert.Equal(t, "key-1", token.Header["kid"])
+			return test.publicKey, nil
+		})
+		assert.Nil(t, err, test.method)
+		assert.True(t, token.Valid, test.method)
+	}
+
+	invalid := []map[string]interface{}{
+		{"signingMethod": "HS256"},
+		{"signingMethod": "none", "key": "secret"},
+		{"signingMethod": "XS256", "key": "secret"},
+		{"signingMethod": "RS256", "key": "secret"},
+		{"signingMethod": "ES256", "keyFile": tests[0].file},
+		{"signingMethod": "HS256", "keyFile": filepath.Join(dir, "missing.pem")},
+		{"signingMethod": "HS256", "keyEnv": "JWT_ISSUER_TEST_MISSING"},
+	}
+	for _, settings := range invalid {
+		_, err := New(newInitContext(settings))
+		assert.NotNil(t, err, settings)
+	}
+}
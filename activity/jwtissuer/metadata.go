@@ -0,0 +1,82 @@
+package jwtissuer
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the JWT issuer
+type Settings struct {
+	SigningMethod string `md:"signingMethod,required"`
+	Key           string `md:"key"`
+	KeyFile       string `md:"keyFile"`
+	KeyEnv        string `md:"keyEnv"`
+	KeyID         string `md:"kid"`
+	Issuer        string `md:"iss"`
+	Audience      string `md:"aud"`
+	TTL           int    `md:"ttl"`
+}
+
+// Input is the input for the JWT issuer
+type Input struct {
+	Claims   map[string]interface{} `md:"claims"`
+	Subject  string                 `md:"sub"`
+	Audience string                 `md:"aud"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	claims, err := coerce.ToObject(values["claims"])
+	if err != nil {
+		return err
+	}
+	r.Claims = claims
+	subject, err := coerce.ToString(values["sub"])
+	if err != nil {
+		return err
+	}
+	r.Subject = subject
+	audience, err := coerce.ToString(values["aud"])
+	if err != nil {
+		return err
+	}
+	r.Audience = audience
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"claims": r.Claims,
+		"sub":    r.Subject,
+		"aud":    r.Audience,
+	}
+}
+
+// Output is the output of the JWT issuer
+type Output struct {
+	Token     string `md:"token"`
+	ExpiresAt int64  `md:"expiresAt"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	token, err := coerce.ToString(values["token"])
+	if err != nil {
+		return err
+	}
+	o.Token = token
+	expiresAt, err := coerce.ToInt64(values["expiresAt"])
+	if err != nil {
+		return err
+	}
+	o.ExpiresAt = expiresAt
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"token":     o.Token,
+		"expiresAt": o.ExpiresAt,
+	}
+}
@@ -0,0 +1,75 @@
+package limits
+
+import (
+	"errors"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates new payload limits
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	if settings.MaxSize < 0 || settings.MaxDepth < 0 || settings.MaxArrayLength < 0 ||
+		settings.MaxStringLength < 0 || settings.MaxKeys < 0 {
+		return nil, errors.New("limits should not be negative")
+	}
+	act := &Activity{
+		limits: Limits{
+			Size:         settings.MaxSize,
+			Depth:        settings.MaxDepth,
+			ArrayLength:  settings.MaxArrayLength,
+			StringLength: settings.MaxStringLength,
+			Keys:         settings.MaxKeys,
+		},
+	}
+	return act, nil
+}
+
+// Activity enforces limits on the size and structure of payloads
+type Activity struct {
+	limits Limits
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := Output{Valid: true}
+	if violation := a.limits.Check(input.Content); violation != nil {
+		output.Valid = false
+		output.Violation = violation.ToMap()
+		output.ValidationMessage = violation.Error()
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,220 @@
+package limits
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func TestLimits(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"maxSize":         150,
+		"maxDepth":        3,
+		"maxArrayLength":  3,
+		"maxStringLength": 8,
+		"maxKeys":         4,
+	}))
+	assert.Nil(t, err)
+
+	test := func(content interface{}, limit, pointer string) {
+		ctx := newActivityContext(map[string]interface{}{
+			"content": content,
+		})
+		_, err := act.Eval(ctx)
+		assert.Nil(t, err)
+		if limit == "" {
+			assert.Equal(t, true, ctx.output["valid"], content)
+			assert.Nil(t, ctx.output["violation"])
+			return
+		}
+		assert.Equal(t, false, ctx.output["valid"], content)
+		violation := ctx.output["violation"].(map[string]interface{})
+		assert.Equal(t, limit, violation["limit"])
+		assert.Equal(t, pointer, violation["pointer"])
+		assert.Equal(t, violation["message"], ctx.output["validationMessage"])
+	}
+
+	test(nil, "", "")
+	test(map[string]interface{}{
+		"name": "sally",
+		"tags": []interface{}{"a", "b", map[string]interface{}{"c": 1}},
+	}, "", "")
+	test(map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": map[string]interface{}{}}},
+	}, LimitDepth, "/a/b/c")
+	test(map[string]interface{}{
+		"a": []interface{}{[]interface{}{[]interface{}{[]interface{}{}}}},
+	}, LimitDepth, "/a/0/0")
+	test(map[string]interface{}{
+		"tags": []interface{}{1, 2, 3, 4},
+	}, LimitArrayLength, "/tags")
+	test(map[string]interface{}{
+		"a": map[string]interface{}{"b/c": "sally is a dog"},
+	}, LimitStringLength, "/a/b~1c")
+	test(map[string]interface{}{
+		"averylongkey": 1,
+	}, LimitStringLength, "/averylongkey")
+	test(map[string]interface{}{
+		"a": 1, "b": 2, "c": 3, "d": 4, "e": 5,
+	}, LimitKeys, "")
+	test(map[string]interface{}{
+		"a": "12345678", "b": "12345678", "c": "12345678",
+	}, "", "")
+	test(map[string]interface{}{
+		"a": []interface{}{strings.Repeat("a", 8), strings.Repeat("b", 8), strings.Repeat("c", 8)},
+		"b": []interface{}{strings.Repeat("a", 8), strings.Repeat("b", 8), strings.Repeat("c", 8)},
+		"c": []interface{}{strings.Repeat("a", 8), strings.Repeat("b", 8), strings.Repeat("c", 8)},
+		"d": []interface{}{strings.Repeat("a", 8), strings.Repeat("b", 8), strings.Repeat("c", 8)},
+	}, LimitSize, "")
+	test(`{"a": {"b": {"c": {}}}}`, LimitDepth, "/a/b/c")
+	test([]byte(`["sally", "bob"]`), "", "")
+	test(`not json but a long string`, "", "")
+	test(`{"a": 1`, LimitSyntax, "")
+	test(` [1, 2,]`, LimitSyntax, "")
+	test(strings.Repeat(" ", 151), LimitSize, "")
+
+	// the first violation in document order is reported
+	test(map[string]interface{}{
+		"a": []interface{}{1, 2, 3, 4},
+		"b": "a long string",
+	}, LimitArrayLength, "/a")
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"maxDepth": -1,
+	}))
+	assert.NotNil(t, err)
+}
+
+func TestViolation(t *testing.T) {
+	limits := Limits{StringLength: 3}
+	violation := limits.Check(map[string]interface{}{"a": []interface{}{"héllo"}})
+	assert.Equal(t, map[string]interface{}{
+		"limit":   LimitStringLength,
+		"pointer": "/a/0",
+		"value":   5,
+		"max":     3,
+		"message": "string at /a/0 has 5 characters, the maximum is 3",
+	}, violation.ToMap())
+
+	limits = Limits{Size: 10}
+	violation = limits.Check(map[string]interface{}{"name": "sally"})
+	assert.Equal(t, "body size 16 exceeds the maximum of 10 bytes", violation.Error())
+
+	limits = Limits{Keys: 1}
+	violation = limits.Check(map[string]interface{}{"a": 1, "b": 2})
+	assert.Equal(t, "object at / has 2 keys, the maximum is 1", violation.Error())
+
+	// documents nested deeper than the JSON parser allows can't be walked
+	limits = Limits{}
+	nested := strings.Repeat("[", 10001) + strings.Repeat("]", 10001)
+	violation = limits.Check(nested)
+	assert.NotNil(t, violation, "content which can't be parsed should not be valid")
+	assert.Equal(t, LimitSyntax, violation.Limit)
+	assert.Equal(t, "body starts like a JSON document but isn't valid JSON", violation.Error())
+	assert.Nil(t, limits.Check(strings.Repeat("[", 100)+strings.Repeat("]", 100)))
+}
@@ -0,0 +1,183 @@
+package limits
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// LimitSize is the limit on the size of the body in bytes
+	LimitSize = "size"
+	// LimitDepth is the limit on the nesting depth of objects and arrays
+	LimitDepth = "depth"
+	// LimitArrayLength is the limit on the number of elements of an array
+	LimitArrayLength = "arrayLength"
+	// LimitStringLength is the limit on the number of characters of a string or key
+	LimitStringLength = "stringLength"
+	// LimitKeys is the limit on the number of keys of an object
+	LimitKeys = "keys"
+	// LimitSyntax is violated by a body which looks like a JSON document but can't be parsed,
+	// such as a document nested deeper than the parser allows
+	LimitSyntax = "syntax"
+)
+
+// Limits are the limits on the size and structure of a body, a limit of 0 is no limit
+type Limits struct {
+	Size         int
+	Depth        int
+	ArrayLength  int
+	StringLength int
+	Keys         int
+}
+
+// Violation is a body which exceeds a limit
+type Violation struct {
+	Limit   string
+	Pointer string
+	Value   int
+	Max     int
+}
+
+// Error returns the message of the violation
+func (v *Violation) Error() string {
+	switch v.Limit {
+	case LimitSize:
+		return fmt.Sprintf("body size %d exceeds the maximum of %d bytes", v.Value, v.Max)
+	case LimitDepth:
+		return fmt.Sprintf("depth at %s exceeds the maximum of %d", pointerOrRoot(v.Pointer), v.Max)
+	case LimitArrayLength:
+		return fmt.Sprintf("array at %s has %d elements, the maximum is %d", pointerOrRoot(v.Pointer), v.Value, v.Max)
+	case LimitStringLength:
+		return fmt.Sprintf("string at %s has %d characters, the maximum is %d", pointerOrRoot(v.Pointer), v.Value, v.Max)
+	case LimitKeys:
+		return fmt.Sprintf("object at %s has %d keys, the maximum is %d", pointerOrRoot(v.Pointer), v.Value, v.Max)
+	case LimitSyntax:
+		return "body starts like a JSON document but isn't valid JSON"
+	}
+	return fmt.Sprintf("%s at %s exceeds the maximum of %d", v.Limit, pointerOrRoot(v.Pointer), v.Max)
+}
+
+// ToMap converts the violation to a map
+func (v *Violation) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"limit":   v.Limit,
+		"pointer": v.Pointer,
+		"value":   v.Value,
+		"max":     v.Max,
+		"message": v.Error(),
+	}
+}
+
+func pointerOrRoot(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
+}
+
+// Check checks a body against the limits and returns the first violation. A body which is
+// a string or bytes is checked for its size, and for its structure when it starts like a JSON
+// document, it is then a violation when it isn't valid JSON.
+// Otherwise the size is the size of the JSON encoding of the body. The structure is walked
+// in document order, with the keys of an object in sorted order
+func (l *Limits) Check(body interface{}) *Violation {
+	raw, isRaw := body.([]byte)
+	if s, ok := body.(string); ok {
+		raw, isRaw = []byte(s), true
+	}
+	if isRaw {
+		if l.Size > 0 && len(raw) > l.Size {
+			return &Violation{Limit: LimitSize, Value: len(raw), Max: l.Size}
+		}
+		trimmed := strings.TrimSpace(string(raw))
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			return nil
+		}
+		var document interface{}
+		if json.Unmarshal(raw, &document) != nil {
+			return &Violation{Limit: LimitSyntax}
+		}
+		return l.walk(document, "", 0)
+	}
+
+	if violation := l.walk(body, "", 0); violation != nil {
+		return violation
+	}
+	if l.Size > 0 {
+		counter := counter(0)
+		if json.NewEncoder(&counter).Encode(body) == nil {
+			// the encoder terminates the document with a new line
+			if size := int(counter) - 1; size > l.Size {
+				return &Violation{Limit: LimitSize, Value: size, Max: l.Size}
+			}
+		}
+	}
+	return nil
+}
+
+// walk checks a value at the given JSON pointer and depth
+func (l *Limits) walk(value interface{}, pointer string, depth int) *Violation {
+	switch value := value.(type) {
+	case string:
+		if l.StringLength > 0 {
+			if length := utf8.RuneCountInString(value); length > l.StringLength {
+				return &Violation{Limit: LimitStringLength, Pointer: pointer, Value: length, Max: l.StringLength}
+			}
+		}
+	case map[string]interface{}:
+		depth++
+		if l.Depth > 0 && depth > l.Depth {
+			return &Violation{Limit: LimitDepth, Pointer: pointer, Value: depth, Max: l.Depth}
+		}
+		if l.Keys > 0 && len(value) > l.Keys {
+			return &Violation{Limit: LimitKeys, Pointer: pointer, Value: len(value), Max: l.Keys}
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := pointer + "/" + escape(key)
+			if l.StringLength > 0 {
+				if length := utf8.RuneCountInString(key); length > l.StringLength {
+					return &Violation{Limit: LimitStringLength, Pointer: child, Value: length, Max: l.StringLength}
+				}
+			}
+			if violation := l.walk(value[key], child, depth); violation != nil {
+				return violation
+			}
+		}
+	case []interface{}:
+		depth++
+		if l.Depth > 0 && depth > l.Depth {
+			return &Violation{Limit: LimitDepth, Pointer: pointer, Value: depth, Max: l.Depth}
+		}
+		if l.ArrayLength > 0 && len(value) > l.ArrayLength {
+			return &Violation{Limit: LimitArrayLength, Pointer: pointer, Value: len(value), Max: l.ArrayLength}
+		}
+		for i, element := range value {
+			if violation := l.walk(element, pointer+"/"+strconv.Itoa(i), depth); violation != nil {
+				return violation
+			}
+		}
+	}
+	return nil
+}
+
+// escape escapes a key for a JSON pointer as defined in RFC 6901
+func escape(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+// counter counts the bytes written to it
+type counter int
+
+func (c *counter) Write(p []byte) (int, error) {
+	*c += counter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,68 @@
+package limits
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the payload limits
+type Settings struct {
+	MaxSize         int `md:"maxSize"`
+	MaxDepth        int `md:"maxDepth"`
+	MaxArrayLength  int `md:"maxArrayLength"`
+	MaxStringLength int `md:"maxStringLength"`
+	MaxKeys         int `md:"maxKeys"`
+}
+
+// Input is the input for the payload limits
+type Input struct {
+	Content interface{} `md:"content"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	r.Content = values["content"]
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"content": r.Content,
+	}
+}
+
+// Output is the output of the payload limits
+type Output struct {
+	Valid             bool                   `md:"valid"`
+	Violation         map[string]interface{} `md:"violation"`
+	ValidationMessage string                 `md:"validationMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	valid, err := coerce.ToBool(values["valid"])
+	if err != nil {
+		return err
+	}
+	o.Valid = valid
+	violation, err := coerce.ToObject(values["violation"])
+	if err != nil {
+		return err
+	}
+	o.Violation = violation
+	validationMessage, err := coerce.ToString(values["validationMessage"])
+	if err != nil {
+		return err
+	}
+	o.ValidationMessage = validationMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"valid":             o.Valid,
+		"violation":         o.Violation,
+		"validationMessage": o.ValidationMessage,
+	}
+}
@@ -0,0 +1,181 @@
+package loadbalancer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+	"github.com/project-flogo/microgateway/api"
+)
+
+const (
+	// OperationSelect selects a target
+	OperationSelect = "select"
+	// OperationSuccess reports a successful request to a target
+	OperationSuccess = "success"
+	// OperationFailure reports a failed request to a target
+	OperationFailure = "failure"
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+	// Now returns the current time
+	Now = time.Now
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new load balancer
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{
+		MaxFailures:         3,
+		EjectTime:           30,
+		HealthCheckInterval: 10,
+		HealthCheckTimeout:  1000,
+	}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	if settings.HealthCheckInterval <= 0 || settings.HealthCheckTimeout <= 0 {
+		return nil, errors.New("healthCheckInterval and healthCheckTimeout should be greater than 0")
+	}
+	targets, err := ParseTargets(settings.Targets)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := NewPool(targets, settings.Strategy, settings.MaxFailures,
+		time.Duration(settings.EjectTime)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{
+		pool:       pool,
+		selections: make(map[selection]int, 256),
+	}
+	if settings.HealthCheckPath != "" {
+		act.checker = NewHealthChecker(pool, settings.HealthCheckPath,
+			time.Duration(settings.HealthCheckInterval)*time.Second,
+			time.Duration(settings.HealthCheckTimeout)*time.Millisecond)
+	}
+	return act, nil
+}
+
+// selection identifies the requests in flight to a target of a microgateway execution
+type selection struct {
+	host   activity.Host
+	target *Target
+}
+
+// Activity is a load balancer which selects a target from a pool of backends
+type Activity struct {
+	pool    *Pool
+	checker *HealthChecker
+
+	sync.Mutex
+	selections map[selection]int
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Cleanup stops the health checks
+func (a *Activity) Cleanup() error {
+	if a.checker != nil {
+		a.checker.Stop()
+	}
+	return nil
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	host, output := ctx.ActivityHost(), Output{}
+	switch input.Operation {
+	case "", OperationSelect:
+		target, err := a.pool.Select(input.Key)
+		if err != nil {
+			output.Error = true
+			output.ErrorMessage = err.Error()
+			break
+		}
+		output.URL, output.Target = target.URL, target.Name
+		key := selection{host: host, target: target}
+		a.Lock()
+		a.selections[key]++
+		a.Unlock()
+		// release the request in flight when the execution ends without reporting it
+		if deferrer, ok := host.(api.Deferrer); ok {
+			deferrer.Defer(func() {
+				a.release(key)
+			})
+		}
+	case OperationSuccess, OperationFailure:
+		target := a.selected(host, input.Target)
+		if target == nil {
+			output.Error = true
+			output.ErrorMessage = fmt.Sprintf("unknown target: %s", input.Target)
+			break
+		}
+		output.URL, output.Target = target.URL, target.Name
+		a.pool.Report(target, input.Operation == OperationSuccess)
+		a.release(selection{host: host, target: target})
+	default:
+		return false, fmt.Errorf("unknown operation: %s", input.Operation)
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// selected returns the target with the given name or URL, or the target selected by the execution
+func (a *Activity) selected(host activity.Host, name string) *Target {
+	if name != "" {
+		return a.pool.Target(name)
+	}
+	a.Lock()
+	defer a.Unlock()
+	for key := range a.selections {
+		if key.host == host {
+			return key.target
+		}
+	}
+	return nil
+}
+
+// release releases a request in flight selected by an execution
+func (a *Activity) release(key selection) {
+	a.Lock()
+	count := a.selections[key]
+	if count == 0 {
+		a.Unlock()
+		return
+	}
+	if count == 1 {
+		delete(a.selections, key)
+	} else {
+		a.selections[key] = count - 1
+	}
+	a.Unlock()
+	a.pool.Release(key.target)
+}
@@ -0,0 +1,300 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input    map[string]interface{}
+	output   map[string]interface{}
+	deferred []func()
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func (a *activityContext) Defer(f func()) {
+	a.deferred = append(a.deferred, f)
+}
+
+func (a *activityContext) runDeferred() {
+	for _, f := range a.deferred {
+		f()
+	}
+	a.deferred = nil
+}
+
+func TestLoadBalancerStrategies(t *testing.T) {
+	targets := []interface{}{
+		"http://a.example.com",
+		map[string]interface{}{"name": "b", "url": "http://b.example.com", "weight": 2},
+		map[string]interface{}{"name": "c", "url": "http://c.example.com", "weight": 1},
+	}
+	newActivity := func(strategy string) activity.Activity {
+		settings := map[string]interface{}{"targets": targets}
+		if strategy != "" {
+			settings["strategy"] = strategy
+		}
+		act, err := New(newInitContext(settings))
+		assert.Nil(t, err)
+		return act
+	}
+	selectTarget := func(act activity.Activity, key string) *activityContext {
+		ctx := newActivityContext(map[string]interface{}{"key": key})
+		_, err := act.Eval(ctx)
+		assert.Nil(t, err)
+		assert.False(t, ctx.output["error"].(bool))
+		return ctx
+	}
+
+	act := newActivity("")
+	var names []interface{}
+	for i := 0; i < 4; i++ {
+		names = append(names, selectTarget(act, "").output["target"])
+	}
+	assert.Equal(t, []interface{}{"http://a.example.com", "b", "c", "http://a.example.com"}, names)
+	assert.Equal(t, "http://b.example.com", selectTarget(act, "").output["url"])
+
+	act = newActivity(StrategyWeighted)
+	counts := make(map[interface{}]int)
+	for i := 0; i < 8; i++ {
+		counts[selectTarget(act, "").output["target"]]++
+	}
+	assert.Equal(t, map[interface{}]int{"http://a.example.com": 2, "b": 4, "c": 2}, counts)
+
+	act = newActivity(StrategyLeastInFlight)
+	a := selectTarget(act, "")
+	b := selectTarget(act, "")
+	c := selectTarget(act, "")
+	assert.NotEqual(t, a.output["target"], b.output["target"])
+	assert.NotEqual(t, b.output["target"], c.output["target"])
+	assert.NotEqual(t, a.output["target"], c.output["target"])
+	b.runDeferred()
+	assert.Equal(t, b.output["target"], selectTarget(act, "").output["target"], "least in flight target should be selected")
+
+	act = newActivity(StrategyConsistentHash)
+	counts = make(map[interface{}]int)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user%d", i)
+		target := selectTarget(act, key).output["target"]
+		assert.Equal(t, target, selectTarget(act, key).output["target"], "same key should select same target")
+		counts[target]++
+	}
+	assert.Len(t, counts, 3)
+
+	for _, settings := range []map[string]interface{}{
+		{},
+		{"targets": []interface{}{"not a url"}},
+		{"targets": []interface{}{map[string]interface{}{"url": "http://a", "weight": 0}}},
+		{"targets": []interface{}{"http://a", "http://a"}},
+		{"targets": []interface{}{"http://a"}, "strategy": "random"},
+	} {
+		_, err := New(newInitContext(settings))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestLoadBalancerEjection(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	Now = func() time.Time {
+		return now
+	}
+	defer func() {
+		Now = time.Now
+	}()
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"targets":     []interface{}{"http://a.example.com", "http://b.example.com"},
+		"maxFailures": 2,
+		"ejectTime":   10,
+	}))
+	assert.Nil(t, err)
+	eval := func(ctx *activityContext, input map[string]interface{}) map[string]interface{} {
+		ctx.input = input
+		_, err := act.Eval(ctx)
+		assert.Nil(t, err)
+		return ctx.output
+	}
+
+	for i := 0; i < 2; i++ {
+		ctx := newActivityContext(nil)
+		output := eval(ctx, map[string]interface{}{})
+		assert.Equal(t, "http://a.example.com", output["url"])
+		output = eval(ctx, map[string]interface{}{"operation": "failure"})
+		assert.Equal(t, "http://a.example.com", output["url"])
+		assert.Equal(t, 0, act.(*Activity).pool.InFlight(act.(*Activity).pool.Target("http://a.example.com")))
+		ctx.runDeferred()
+		eval(newActivityContext(nil), map[string]interface{}{"operation": "success", "target": "http://b.example.com"})
+		eval(newActivityContext(nil), map[string]interface{}{})
+	}
+	for i := 0; i < 3; i++ {
+		output := eval(newActivityContext(nil), map[string]interface{}{})
+		assert.Equal(t, "http://b.example.com", output["url"], "failing target should be ejected")
+	}
+
+	now = now.Add(11 * time.Second)
+	urls := make(map[interface{}]bool)
+	for i := 0; i < 2; i++ {
+		urls[eval(newActivityContext(nil), map[string]interface{}{})["url"]] = true
+	}
+	assert.True(t, urls["http://a.example.com"], "ejected target should come back")
+
+	output := eval(newActivityContext(nil), map[string]interface{}{"operation": "failure"})
+	assert.True(t, output["error"].(bool), "reporting without a target should fail")
+	assert.Equal(t, "unknown target: ", output["errorMessage"])
+}
+
+func TestLoadBalancerHealthCheck(t *testing.T) {
+	var healthy int32 = 1
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer a.Close()
+	b := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer b.Close()
+	c := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	c.Close()
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"targets":         []interface{}{a.URL, b.URL, c.URL},
+		"healthCheckPath": "/health",
+	}))
+	assert.Nil(t, err)
+	defer act.(*Activity).Cleanup()
+	pool := act.(*Activity).pool
+	client := &http.Client{Timeout: time.Second}
+	selectURLs := func() map[interface{}]bool {
+		urls := make(map[interface{}]bool)
+		for i := 0; i < 6; i++ {
+			ctx := newActivityContext(nil)
+			_, err := act.Eval(ctx)
+			assert.Nil(t, err)
+			if ctx.output["error"].(bool) {
+				urls[ctx.output["errorMessage"]] = true
+			} else {
+				urls[ctx.output["url"]] = true
+			}
+		}
+		return urls
+	}
+
+	pool.Check(client, "/health")
+	assert.Equal(t, map[interface{}]bool{a.URL: true, b.URL: true}, selectURLs())
+
+	atomic.StoreInt32(&healthy, 0)
+	pool.Check(client, "/health")
+	assert.Equal(t, map[interface{}]bool{b.URL: true}, selectURLs())
+
+	b.Close()
+	pool.Check(client, "/health")
+	assert.Equal(t, map[interface{}]bool{ErrorNoHealthyTarget.Error(): true}, selectURLs())
+
+	atomic.StoreInt32(&healthy, 1)
+	pool.Check(client, "/health")
+	assert.Equal(t, map[interface{}]bool{a.URL: true}, selectURLs())
+}
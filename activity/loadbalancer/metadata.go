@@ -0,0 +1,95 @@
+package loadbalancer
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the load balancer
+type Settings struct {
+	Targets             []interface{} `md:"targets,required"`
+	Strategy            string        `md:"strategy,allowed(roundRobin,leastInFlight,weighted,consistentHash)"`
+	MaxFailures         int           `md:"maxFailures"`
+	EjectTime           int           `md:"ejectTime"`
+	HealthCheckPath     string        `md:"healthCheckPath"`
+	HealthCheckInterval int           `md:"healthCheckInterval"`
+	HealthCheckTimeout  int           `md:"healthCheckTimeout"`
+}
+
+// Input is the input for the load balancer
+type Input struct {
+	Operation string `md:"operation,allowed(select,success,failure)"`
+	Key       string `md:"key"`
+	Target    string `md:"target"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	operation, err := coerce.ToString(values["operation"])
+	if err != nil {
+		return err
+	}
+	r.Operation = operation
+	key, err := coerce.ToString(values["key"])
+	if err != nil {
+		return err
+	}
+	r.Key = key
+	target, err := coerce.ToString(values["target"])
+	if err != nil {
+		return err
+	}
+	r.Target = target
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"operation": r.Operation,
+		"key":       r.Key,
+		"target":    r.Target,
+	}
+}
+
+// Output is the output of the load balancer
+type Output struct {
+	URL          string `md:"url"`
+	Target       string `md:"target"`
+	Error        bool   `md:"error"`
+	ErrorMessage string `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	url, err := coerce.ToString(values["url"])
+	if err != nil {
+		return err
+	}
+	o.URL = url
+	target, err := coerce.ToString(values["target"])
+	if err != nil {
+		return err
+	}
+	o.Target = target
+	hasError, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = hasError
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"url":          o.URL,
+		"target":       o.Target,
+		"error":        o.Error,
+		"errorMessage": o.ErrorMessage,
+	}
+}
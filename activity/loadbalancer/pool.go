@@ -0,0 +1,318 @@
+package loadbalancer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-flogo/core/data/coerce"
+)
+
+const (
+	// StrategyRoundRobin selects the healthy targets in turn
+	StrategyRoundRobin = "roundRobin"
+	// StrategyLeastInFlight selects the healthy target with the fewest requests in flight
+	StrategyLeastInFlight = "leastInFlight"
+	// StrategyWeighted selects the healthy targets in turn in proportion to their weights
+	StrategyWeighted = "weighted"
+	// StrategyConsistentHash selects the same healthy target for the same key
+	StrategyConsistentHash = "consistentHash"
+	// VirtualNodes is the number of points of a target with weight 1 on the consistent hash ring
+	VirtualNodes = 64
+)
+
+var (
+	// ErrorNoHealthyTarget happens when all of the targets are unhealthy or ejected
+	ErrorNoHealthyTarget = errors.New("no healthy target")
+)
+
+// Target is a backend of the pool
+type Target struct {
+	Name   string
+	URL    string
+	Weight int
+
+	inFlight      int
+	failures      int
+	ejectedUntil  time.Time
+	unhealthy     bool
+	currentWeight int
+}
+
+// ParseTargets parses the targets from the settings, a target is a URL or an object with a url, a name and a weight
+func ParseTargets(values []interface{}) ([]*Target, error) {
+	targets := make([]*Target, 0, len(values))
+	names := make(map[string]bool, len(values))
+	for i, value := range values {
+		target := &Target{Weight: 1}
+		if s, ok := value.(string); ok {
+			target.URL = s
+		} else {
+			object, err := coerce.ToObject(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid target %d: %v", i, err)
+			}
+			for key, field := range object {
+				switch key {
+				case "url":
+					target.URL, err = coerce.ToString(field)
+				case "name":
+					target.Name, err = coerce.ToString(field)
+				case "weight":
+					target.Weight, err = coerce.ToInt(field)
+				default:
+					err = fmt.Errorf("unknown field '%s'", key)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("invalid target %d: %v", i, err)
+				}
+			}
+		}
+		parsed, err := url.Parse(target.URL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid url for target %d: %s", i, target.URL)
+		}
+		if target.Weight <= 0 {
+			return nil, fmt.Errorf("weight of target %d should be greater than 0", i)
+		}
+		if target.Name == "" {
+			target.Name = target.URL
+		}
+		if names[target.Name] {
+			return nil, fmt.Errorf("duplicate target: %s", target.Name)
+		}
+		names[target.Name] = true
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("at least one target is required")
+	}
+	return targets, nil
+}
+
+// Pool is a pool of targets with passive and active health checking
+type Pool struct {
+	strategy    string
+	maxFailures int
+	ejectTime   time.Duration
+
+	sync.Mutex
+	targets []*Target
+	next    int
+	ring    []ringPoint
+}
+
+type ringPoint struct {
+	hash   uint64
+	target *Target
+}
+
+// NewPool creates a new pool. A target is ejected for ejectTime after maxFailures consecutive failures
+func NewPool(targets []*Target, strategy string, maxFailures int, ejectTime time.Duration) (*Pool, error) {
+	pool := &Pool{
+		strategy:    strategy,
+		maxFailures: maxFailures,
+		ejectTime:   ejectTime,
+		targets:     targets,
+	}
+	switch strategy {
+	case "":
+		pool.strategy = StrategyRoundRobin
+	case StrategyRoundRobin, StrategyLeastInFlight, StrategyWeighted:
+	case StrategyConsistentHash:
+		for _, target := range targets {
+			for i := 0; i < VirtualNodes*target.Weight; i++ {
+				pool.ring = append(pool.ring, ringPoint{
+					hash:   hash(fmt.Sprintf("%s#%d", target.Name, i)),
+					target: target,
+				})
+			}
+		}
+		sort.Slice(pool.ring, func(i, j int) bool {
+			return pool.ring[i].hash < pool.ring[j].hash
+		})
+	default:
+		return nil, fmt.Errorf("unknown strategy: %s", strategy)
+	}
+	return pool, nil
+}
+
+func hash(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// available is true if the target is healthy and not ejected
+func (p *Pool) available(target *Target, now time.Time) bool {
+	return !target.unhealthy && !now.Before(target.ejectedUntil)
+}
+
+// Select selects a healthy target and counts a request in flight for it
+func (p *Pool) Select(key string) (*Target, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	now := Now()
+	var selected *Target
+	switch p.strategy {
+	case StrategyRoundRobin:
+		for i := range p.targets {
+			target := p.targets[(p.next+i)%len(p.targets)]
+			if p.available(target, now) {
+				selected, p.next = target, (p.next+i+1)%len(p.targets)
+				break
+			}
+		}
+	case StrategyLeastInFlight:
+		for i := range p.targets {
+			target := p.targets[(p.next+i)%len(p.targets)]
+			if p.available(target, now) && (selected == nil || target.inFlight < selected.inFlight) {
+				selected = target
+			}
+		}
+		p.next = (p.next + 1) % len(p.targets)
+	case StrategyWeighted:
+		// smooth weighted round robin
+		total := 0
+		for _, target := range p.targets {
+			if !p.available(target, now) {
+				continue
+			}
+			target.currentWeight += target.Weight
+			total += target.Weight
+			if selected == nil || target.currentWeight > selected.currentWeight {
+				selected = target
+			}
+		}
+		if selected != nil {
+			selected.currentWeight -= total
+		}
+	case StrategyConsistentHash:
+		point := hash(key)
+		start := sort.Search(len(p.ring), func(i int) bool {
+			return p.ring[i].hash >= point
+		})
+		for i := range p.ring {
+			target := p.ring[(start+i)%len(p.ring)].target
+			if p.available(target, now) {
+				selected = target
+				break
+			}
+		}
+	}
+	if selected == nil {
+		return nil, ErrorNoHealthyTarget
+	}
+	selected.inFlight++
+	return selected, nil
+}
+
+// Target returns the target with the given name or URL
+func (p *Pool) Target(name string) *Target {
+	for _, target := range p.targets {
+		if target.Name == name || target.URL == name {
+			return target
+		}
+	}
+	return nil
+}
+
+// Release counts the end of a request in flight for the target
+func (p *Pool) Release(target *Target) {
+	p.Lock()
+	defer p.Unlock()
+	if target.inFlight > 0 {
+		target.inFlight--
+	}
+}
+
+// Report records the result of a request to the target, the target is ejected after too many consecutive failures
+func (p *Pool) Report(target *Target, success bool) {
+	p.Lock()
+	defer p.Unlock()
+	if success {
+		target.failures = 0
+		return
+	}
+	target.failures++
+	if p.maxFailures > 0 && target.failures >= p.maxFailures {
+		target.failures = 0
+		target.ejectedUntil = Now().Add(p.ejectTime)
+	}
+}
+
+// InFlight returns the number of requests in flight for the target
+func (p *Pool) InFlight(target *Target) int {
+	p.Lock()
+	defer p.Unlock()
+	return target.inFlight
+}
+
+// Check checks the health of all of the targets by requesting path.
+// A target is healthy if it responds with a status code below 400
+func (p *Pool) Check(client *http.Client, path string) {
+	results := make([]bool, len(p.targets))
+	wait := sync.WaitGroup{}
+	for i, target := range p.targets {
+		wait.Add(1)
+		go func(i int, target *Target) {
+			defer wait.Done()
+			response, err := client.Get(strings.TrimSuffix(target.URL, "/") + path)
+			if err != nil {
+				return
+			}
+			response.Body.Close()
+			results[i] = response.StatusCode < http.StatusBadRequest
+		}(i, target)
+	}
+	wait.Wait()
+
+	p.Lock()
+	defer p.Unlock()
+	for i, target := range p.targets {
+		target.unhealthy = !results[i]
+	}
+}
+
+// HealthChecker checks the health of the targets of a pool periodically
+type HealthChecker struct {
+	done chan struct{}
+	once sync.Once
+}
+
+// NewHealthChecker starts checking the health of the targets of pool every interval
+func NewHealthChecker(pool *Pool, path string, interval, timeout time.Duration) *HealthChecker {
+	checker := &HealthChecker{
+		done: make(chan struct{}),
+	}
+	client := &http.Client{
+		Timeout: timeout,
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			pool.Check(client, path)
+			select {
+			case <-ticker.C:
+			case <-checker.done:
+				return
+			}
+		}
+	}()
+	return checker
+}
+
+// Stop stops the health checks
+func (h *HealthChecker) Stop() {
+	h.once.Do(func() {
+		close(h.done)
+	})
+}
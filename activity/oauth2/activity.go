@@ -0,0 +1,277 @@
+package oauth2
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/coerce"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+const (
+	// DefaultTimeout is the default number of milliseconds to wait for the introspection endpoint
+	DefaultTimeout = 5000
+	// DefaultCacheTTL is the default maximum number of seconds an active token is cached
+	DefaultCacheTTL = 300
+	// DefaultNegativeCacheTTL is the default number of seconds an inactive token is cached
+	DefaultNegativeCacheTTL = 10
+	// DefaultCacheSize is the default maximum number of cached tokens
+	DefaultCacheSize = 1024
+	// MaxResponseSize is the maximum number of bytes read from the introspection endpoint
+	MaxResponseSize = 1 << 20
+)
+
+var (
+	// ErrorTokenNotFound happens when there is no token to introspect
+	ErrorTokenNotFound = errors.New("token not found")
+	activityMetadata   = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new token introspection activity
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = url.Parse(settings.IntrospectionURL)
+	if err != nil {
+		return nil, err
+	}
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	cacheTTL := settings.CacheTTL
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultCacheTTL
+	}
+	negativeCacheTTL := settings.NegativeCacheTTL
+	if negativeCacheTTL <= 0 {
+		negativeCacheTTL = DefaultNegativeCacheTTL
+	}
+	cacheSize := settings.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+
+	act := &Activity{
+		url:          settings.IntrospectionURL,
+		clientID:     settings.ClientID,
+		clientSecret: settings.ClientSecret,
+		client: &http.Client{
+			Timeout: time.Duration(timeout) * time.Millisecond,
+		},
+		cacheTTL:         time.Duration(cacheTTL) * time.Second,
+		negativeCacheTTL: time.Duration(negativeCacheTTL) * time.Second,
+		cacheSize:        cacheSize,
+		entries:          list.New(),
+		index:            make(map[[sha256.Size]byte]*list.Element, 256),
+	}
+	return act, nil
+}
+
+// entry is a cached introspection result
+type entry struct {
+	key        [sha256.Size]byte
+	output     Output
+	expiration time.Time
+}
+
+// Activity is an OAuth2 token introspection client
+type Activity struct {
+	url          string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+
+	cacheTTL, negativeCacheTTL time.Duration
+	cacheSize                  int
+	sync.Mutex
+	entries *list.List
+	index   map[[sha256.Size]byte]*list.Element
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	token := strings.TrimSpace(input.Token)
+	if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+		token = strings.TrimSpace(token[7:])
+	}
+
+	output := Output{}
+	if token == "" {
+		output.Error, output.ErrorMessage = true, ErrorTokenNotFound.Error()
+	} else {
+		output, err = a.introspect(token, time.Now())
+		if err != nil {
+			ctx.Logger().Errorf("token introspection failed: %v", err)
+			output = Output{Error: true, ErrorMessage: err.Error()}
+		}
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// introspect returns the cached introspection result of a token, or asks the introspection endpoint
+func (a *Activity) introspect(token string, now time.Time) (Output, error) {
+	key := sha256.Sum256([]byte(token))
+	if cached, ok := a.get(key, now); ok {
+		return cached, nil
+	}
+
+	output, err := a.request(token)
+	if err != nil {
+		return output, err
+	}
+
+	expiration := now.Add(a.negativeCacheTTL)
+	if output.Active {
+		expiration = now.Add(a.cacheTTL)
+		if output.Expiration > 0 {
+			exp := time.Unix(output.Expiration, 0)
+			if !exp.After(now) {
+				output = Output{}
+				expiration = now.Add(a.negativeCacheTTL)
+			} else if exp.Before(expiration) {
+				expiration = exp
+			}
+		}
+	}
+
+	a.set(&entry{
+		key:        key,
+		output:     output,
+		expiration: expiration,
+	})
+	return output, nil
+}
+
+// get returns the cached introspection result of a token and marks it as recently used
+func (a *Activity) get(key [sha256.Size]byte, now time.Time) (Output, bool) {
+	a.Lock()
+	defer a.Unlock()
+
+	element, ok := a.index[key]
+	if !ok {
+		return Output{}, false
+	}
+	cached := element.Value.(*entry)
+	if !now.Before(cached.expiration) {
+		a.remove(element)
+		return Output{}, false
+	}
+	a.entries.MoveToFront(element)
+	return cached.output, true
+}
+
+// set caches an introspection result, evicting the least recently used results beyond the cache size
+func (a *Activity) set(cached *entry) {
+	a.Lock()
+	defer a.Unlock()
+
+	if element, ok := a.index[cached.key]; ok {
+		a.remove(element)
+	}
+	a.index[cached.key] = a.entries.PushFront(cached)
+	for a.entries.Len() > a.cacheSize {
+		a.remove(a.entries.Back())
+	}
+}
+
+func (a *Activity) remove(element *list.Element) {
+	cached := a.entries.Remove(element).(*entry)
+	delete(a.index, cached.key)
+}
+
+// request posts the token to the introspection endpoint as defined in RFC 7662
+func (a *Activity) request(token string) (Output, error) {
+	output := Output{}
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}
+	request, err := http.NewRequest(http.MethodPost, a.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return output, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if a.clientID != "" {
+		request.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	}
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return output, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, MaxResponseSize+1))
+	if err != nil {
+		return output, err
+	}
+	if len(body) > MaxResponseSize {
+		return output, fmt.Errorf("introspection response is larger than %d bytes", MaxResponseSize)
+	}
+	if response.StatusCode != http.StatusOK {
+		return output, fmt.Errorf("introspection endpoint returned %s", response.Status)
+	}
+
+	claims := make(map[string]interface{})
+	err = json.Unmarshal(body, &claims)
+	if err != nil {
+		return output, fmt.Errorf("invalid introspection response: %v", err)
+	}
+	active, ok := claims["active"].(bool)
+	if !ok {
+		return output, errors.New("invalid introspection response: missing active")
+	}
+	if !active {
+		return output, nil
+	}
+
+	output.Active = true
+	output.Claims = claims
+	output.Scope, _ = coerce.ToString(claims["scope"])
+	output.Subject, _ = coerce.ToString(claims["sub"])
+	output.ClientID, _ = coerce.ToString(claims["client_id"])
+	output.Username, _ = coerce.ToString(claims["username"])
+	if exp, ok := claims["exp"]; ok {
+		output.Expiration, err = coerce.ToInt64(exp)
+		if err != nil {
+			return Output{}, fmt.Errorf("invalid introspection response: %v", err)
+		}
+	}
+	return output, nil
+}
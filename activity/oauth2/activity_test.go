@@ -0,0 +1,316 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+type introspectionServer struct {
+	*httptest.Server
+	sync.Mutex
+	requests map[string]int
+	fail     bool
+	exp      int64
+}
+
+func newIntrospectionServer(t *testing.T) *introspectionServer {
+	server := &introspectionServer{
+		requests: make(map[string]int),
+		exp:      time.Now().Add(time.Hour).Unix(),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.Lock()
+		defer server.Unlock()
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		if id, secret, ok := r.BasicAuth(); !ok || id != "gateway" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if server.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		token := r.PostFormValue("token")
+		server.requests[token]++
+		response := map[string]interface{}{
+			"active": false,
+		}
+		switch token {
+		case "active":
+			response = map[string]interface{}{
+				"active":    true,
+				"scope":     "read write",
+				"sub":       "Z5O3upPC88QrAjx00dis",
+				"client_id": "l238j323ds-23ij4",
+				"username":  "jdoe",
+				"exp":       server.exp,
+				"tenant":    "acme",
+			}
+		case "expired":
+			response = map[string]interface{}{
+				"active": true,
+				"exp":    time.Now().Add(-time.Minute).Unix(),
+			}
+		case "large":
+			response = map[string]interface{}{
+				"active": true,
+				"scope":  strings.Repeat("a", MaxResponseSize),
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	return server
+}
+
+func (s *introspectionServer) count(token string) int {
+	s.Lock()
+	defer s.Unlock()
+	return s.requests[token]
+}
+
+func introspect(t *testing.T, act activity.Activity, token string) map[string]interface{} {
+	ctx := newActivityContext(map[string]interface{}{
+		"token": token,
+	})
+	_, err := act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func TestIntrospection(t *testing.T) {
+	server := newIntrospectionServer(t)
+	defer server.Close()
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"introspectionUrl": server.URL,
+		"clientId":         "gateway",
+		"clientSecret":     "secret",
+	}))
+	assert.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		output := introspect(t, act, "Bearer active")
+		assert.True(t, output["active"].(bool))
+		assert.False(t, output["error"].(bool))
+		assert.Equal(t, "read write", output["scope"])
+		assert.Equal(t, "Z5O3upPC88QrAjx00dis", output["sub"])
+		assert.Equal(t, "l238j323ds-23ij4", output["client_id"])
+		assert.Equal(t, "jdoe", output["username"])
+		assert.Equal(t, server.exp, output["exp"])
+		assert.Equal(t, "acme", output["claims"].(map[string]interface{})["tenant"])
+	}
+	assert.Equal(t, 1, server.count("active"), "active tokens should be cached")
+
+	for i := 0; i < 3; i++ {
+		output := introspect(t, act, "inactive")
+		assert.False(t, output["active"].(bool))
+		assert.False(t, output["error"].(bool))
+	}
+	assert.Equal(t, 1, server.count("inactive"), "inactive tokens should be cached")
+
+	output := introspect(t, act, "expired")
+	assert.False(t, output["active"].(bool))
+
+	output = introspect(t, act, "")
+	assert.False(t, output["active"].(bool))
+	assert.True(t, output["error"].(bool))
+	assert.Equal(t, ErrorTokenNotFound.Error(), output["errorMessage"])
+
+	server.Lock()
+	server.fail = true
+	server.Unlock()
+	for i := 0; i < 2; i++ {
+		output = introspect(t, act, "other")
+		assert.False(t, output["active"].(bool))
+		assert.True(t, output["error"].(bool))
+	}
+	server.Lock()
+	server.fail = false
+	server.Unlock()
+	output = introspect(t, act, "other")
+	assert.False(t, output["error"].(bool), "errors should not be cached")
+}
+
+func TestIntrospectionCacheExpiration(t *testing.T) {
+	server := newIntrospectionServer(t)
+	defer server.Close()
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"introspectionUrl": server.URL,
+		"clientId":         "gateway",
+		"clientSecret":     "secret",
+		"cacheTTL":         7200,
+		"negativeCacheTTL": 5,
+	}))
+	assert.Nil(t, err)
+	introspection := act.(*Activity)
+
+	now := time.Now()
+	output, err := introspection.introspect("active", now)
+	assert.Nil(t, err)
+	assert.True(t, output.Active)
+	_, err = introspection.introspect("active", now.Add(59*time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, server.count("active"))
+	_, err = introspection.introspect("active", now.Add(61*time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, server.count("active"), "active tokens should be cached until exp")
+
+	_, err = introspection.introspect("inactive", now)
+	assert.Nil(t, err)
+	_, err = introspection.introspect("inactive", now.Add(4*time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, server.count("inactive"))
+	_, err = introspection.introspect("inactive", now.Add(6*time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, server.count("inactive"), "inactive tokens should be cached briefly")
+
+	act, err = New(newInitContext(map[string]interface{}{
+		"introspectionUrl": server.URL,
+		"clientId":         "gateway",
+		"clientSecret":     "wrong",
+	}))
+	assert.Nil(t, err)
+	output, err = act.(*Activity).introspect("active", now)
+	assert.NotNil(t, err)
+	assert.False(t, output.Active)
+}
+
+func TestIntrospectionCacheSize(t *testing.T) {
+	server := newIntrospectionServer(t)
+	defer server.Close()
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"introspectionUrl": server.URL,
+		"clientId":         "gateway",
+		"clientSecret":     "secret",
+		"cacheSize":        2,
+	}))
+	assert.Nil(t, err)
+	introspection := act.(*Activity)
+
+	for _, token := range []string{"a", "b", "a", "c", "a", "b"} {
+		_, err = introspection.introspect(token, time.Now())
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, introspection.entries.Len())
+	assert.Equal(t, 1, server.count("a"), "recently used tokens should stay cached")
+	assert.Equal(t, 2, server.count("b"), "least recently used tokens should be evicted")
+	assert.Equal(t, 1, server.count("c"))
+
+	_, err = introspection.introspect("large", time.Now())
+	assert.NotNil(t, err, "large responses should be rejected")
+}
@@ -0,0 +1,116 @@
+package oauth2
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the token introspection
+type Settings struct {
+	IntrospectionURL string `md:"introspectionUrl,required"`
+	ClientID         string `md:"clientId"`
+	ClientSecret     string `md:"clientSecret"`
+	Timeout          int    `md:"timeout"`
+	CacheTTL         int    `md:"cacheTTL"`
+	NegativeCacheTTL int    `md:"negativeCacheTTL"`
+	CacheSize        int    `md:"cacheSize"`
+}
+
+// Input is the input for the token introspection
+type Input struct {
+	Token string `md:"token"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	token, err := coerce.ToString(values["token"])
+	if err != nil {
+		return err
+	}
+	r.Token = token
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"token": r.Token,
+	}
+}
+
+// Output is the output of the token introspection
+type Output struct {
+	Active       bool                   `md:"active"`
+	Scope        string                 `md:"scope"`
+	Subject      string                 `md:"sub"`
+	ClientID     string                 `md:"client_id"`
+	Username     string                 `md:"username"`
+	Expiration   int64                  `md:"exp"`
+	Claims       map[string]interface{} `md:"claims"`
+	Error        bool                   `md:"error"`
+	ErrorMessage string                 `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	active, err := coerce.ToBool(values["active"])
+	if err != nil {
+		return err
+	}
+	o.Active = active
+	scope, err := coerce.ToString(values["scope"])
+	if err != nil {
+		return err
+	}
+	o.Scope = scope
+	subject, err := coerce.ToString(values["sub"])
+	if err != nil {
+		return err
+	}
+	o.Subject = subject
+	clientID, err := coerce.ToString(values["client_id"])
+	if err != nil {
+		return err
+	}
+	o.ClientID = clientID
+	username, err := coerce.ToString(values["username"])
+	if err != nil {
+		return err
+	}
+	o.Username = username
+	expiration, err := coerce.ToInt64(values["exp"])
+	if err != nil {
+		return err
+	}
+	o.Expiration = expiration
+	claims, err := coerce.ToObject(values["claims"])
+	if err != nil {
+		return err
+	}
+	o.Claims = claims
+	e, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = e
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"active":       o.Active,
+		"scope":        o.Scope,
+		"sub":          o.Subject,
+		"client_id":    o.ClientID,
+		"username":     o.Username,
+		"exp":          o.Expiration,
+		"claims":       o.Claims,
+		"error":        o.Error,
+		"errorMessage": o.ErrorMessage,
+	}
+}
@@ -1,21 +1,26 @@
 package ratelimiter
 
 import (
-	"context"
+	"errors"
+	"fmt"
 	"math"
 	"math/rand"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/project-flogo/core/activity"
 	"github.com/project-flogo/core/data/metadata"
 	"github.com/ulule/limiter"
-	"github.com/ulule/limiter/drivers/store/memory"
 )
 
 const (
 	// MemorySize is the size of the circular buffer holding the request times
 	MemorySize = 256
+	// OperationConsume consumes units of the limit
+	OperationConsume = "consume"
+	// OperationRefund returns previously consumed units of the limit
+	OperationRefund = "refund"
 )
 
 var (
@@ -28,55 +33,75 @@ func init() {
 
 // Context is a token context
 type Context struct {
-	sync.Mutex
-	rand              *rand.Rand
 	index, prev, size int
 	lastSpike         int64
 	filter, lastRatio float64
 	memory            [MemorySize]int64
 }
 
+// NewContext creates a new token context
+func NewContext() Context {
+	return Context{
+		prev: MemorySize - 1,
+	}
+}
+
 // Activity is a rate limiter service
 // Limit can be specified in the format "<limit>-<period>"
+// Multiple limits separated by commas are enforced together
 //
 // Valid periods:
 // * "S": second
 // * "M": minute
 // * "H": hour
+// * "D": day
 //
 // Examples:
 // * 5 requests / second : "5-S"
 // * 5 requests / minute : "5-M"
 // * 5 requests / hour : "5-H"
+// * 5 requests / second and 1000 requests / day : "5-S,1000-D"
 type Activity struct {
-	limiter *limiter.Limiter
+	limit      string
+	tiers      map[string]string
+	store      Store
+	rates      map[string][]limiter.Rate
+	failClosed bool
 
-	sync.RWMutex
-	context          map[string]*Context
+	sync.Mutex
+	rand             *rand.Rand
 	threshold, decay float64
 }
 
-func (a *Activity) filterRequests(token string) bool {
-	a.RLock()
-	context := a.context[token]
-	a.RUnlock()
-	if context == nil {
-		context = &Context{
-			prev: MemorySize - 1,
-			rand: rand.New(rand.NewSource(1)),
-		}
+func (a *Activity) filterRequests(token string) (bool, error) {
+	valid, probability := false, 0.0
+	err := a.store.Update(token, func(context *Context) {
+		valid, probability = a.updateContext(context)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if valid {
 		a.Lock()
-		a.context[token] = context
+		sample := a.rand.Float64()
 		a.Unlock()
+		if sample > probability {
+			return true, nil
+		}
 	}
 
-	context.Lock()
-	defer context.Unlock()
+	return false, nil
+}
+
+// updateContext records a request in the context and returns the probability that the request passes
+func (a *Activity) updateContext(context *Context) (valid bool, probability float64) {
 	time := time.Now().UnixNano()
 	previous := context.memory[context.prev]
 	context.memory[context.index] = time
 	context.index, context.prev = (context.index+1)%MemorySize, context.index
-	size, valid := context.size, true
+	size := context.size
+	valid = true
 	if size < MemorySize {
 		size++
 		context.size, valid = size, false
@@ -92,13 +117,10 @@ func (a *Activity) filterRequests(token string) bool {
 			context.lastSpike, context.lastRatio = time, ratio-1
 		}
 
-		probability := 1 / (1 + context.lastRatio*math.Exp(a.decay*float64(context.lastSpike-time)))
-		if context.rand.Float64() > probability {
-			return true
-		}
+		probability = 1 / (1 + context.lastRatio*math.Exp(a.decay*float64(context.lastSpike-time)))
 	}
 
-	return false
+	return valid, probability
 }
 
 // New creates a new rate limiter
@@ -112,22 +134,38 @@ func New(ctx activity.InitContext) (activity.Activity, error) {
 	logger := ctx.Logger()
 	logger.Debugf("Setting: %b", settings)
 
-	rate, err := limiter.NewRateFromFormatted(settings.Limit)
+	if settings.Limit == "" && len(settings.Tiers) == 0 {
+		return nil, errors.New("a limit or tiers are required")
+	}
+	rates := make(map[string][]limiter.Rate, len(settings.Tiers)+1)
+	for _, limit := range append([]string{settings.Limit}, tierLimits(settings.Tiers)...) {
+		if limit == "" {
+			continue
+		}
+		rates[limit], err = ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+	store, err := NewStore(settings.Store, settings.StoreURL, settings.StorePrefix,
+		time.Duration(settings.StoreTTL)*time.Second)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	store := memory.NewStore()
-	limiter := limiter.New(store, rate)
 
 	if settings.DecayRate == 0 {
 		settings.DecayRate = .01
 	}
 
 	act := Activity{
-		limiter:   limiter,
-		context:   make(map[string]*Context, 256),
-		threshold: settings.SpikeThreshold,
-		decay:     settings.DecayRate,
+		limit:      settings.Limit,
+		tiers:      settings.Tiers,
+		store:      store,
+		rates:      rates,
+		failClosed: settings.FailClosed,
+		rand:       rand.New(rand.NewSource(1)),
+		threshold:  settings.SpikeThreshold,
+		decay:      settings.DecayRate,
 	}
 
 	return &act, nil
@@ -160,24 +198,47 @@ func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
 		return true, nil
 	}
 
+	rates, err := a.getRates(&input)
+	if err != nil {
+		return a.setError(ctx, err)
+	}
+
+	cost := int64(input.Cost)
+	if cost < 0 {
+		return a.setError(ctx, fmt.Errorf("invalid cost: %d", cost))
+	} else if cost == 0 {
+		cost = 1
+	}
+	if input.Operation == OperationRefund {
+		cost = -cost
+	}
+
 	// consume limit
-	limiterContext, err := a.limiter.Get(context.TODO(), input.Token)
+	now := time.Now()
+	limiterContext, err := a.consume(input.Token, rates, cost, now)
 	if err != nil {
-		return true, nil
+		return a.setError(ctx, err)
 	}
 
 	filter := false
-	if a.threshold != 0 {
-		filter = a.filterRequests(input.Token)
+	if a.threshold != 0 && input.Operation != OperationRefund {
+		filter, err = a.filterRequests(input.Token)
+		if err != nil {
+			return a.setError(ctx, err)
+		}
 	}
 
 	// check the ratelimit
 	output.LimitAvailable = limiterContext.Remaining
+	output.Limit = limiterContext.Limit
+	output.LimitReset = limiterContext.Reset
 	if limiterContext.Reached || filter {
 		output.LimitReached = true
+		limiterContext.Reached = true
 	} else {
 		output.LimitReached = false
 	}
+	output.Headers = Headers(limiterContext, now)
 
 	err = ctx.SetOutputObject(&output)
 	if err != nil {
@@ -186,3 +247,48 @@ func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
 
 	return true, nil
 }
+
+// Headers returns the standard rate limit response headers for a limiter context.
+// Retry-After is only included when the limit has been reached.
+func Headers(limiterContext limiter.Context, now time.Time) map[string]string {
+	reset := limiterContext.Reset - now.Unix()
+	if reset < 0 {
+		reset = 0
+	}
+	headers := map[string]string{
+		"RateLimit-Limit":     strconv.FormatInt(limiterContext.Limit, 10),
+		"RateLimit-Remaining": strconv.FormatInt(limiterContext.Remaining, 10),
+		"RateLimit-Reset":     strconv.FormatInt(reset, 10),
+	}
+	if limiterContext.Reached {
+		if reset == 0 {
+			reset = 1
+		}
+		headers["Retry-After"] = strconv.FormatInt(reset, 10)
+	}
+	return headers
+}
+
+func tierLimits(tiers map[string]string) []string {
+	limits := make([]string, 0, len(tiers))
+	for _, limit := range tiers {
+		limits = append(limits, limit)
+	}
+	return limits
+}
+
+// setError sets the error outputs, when the limiter fails closed the limit is reported as
+// reached so that requests are rejected when the limit can't be applied
+func (a *Activity) setError(ctx activity.Context, err error) (done bool, e error) {
+	ctx.Logger().Errorf("rate limiter error: %v", err)
+	output := Output{
+		LimitReached: a.failClosed,
+		Error:        true,
+		ErrorMessage: err.Error(),
+	}
+	e = ctx.SetOutputObject(&output)
+	if e != nil {
+		return false, e
+	}
+	return true, nil
+}
@@ -1,9 +1,12 @@
 package ratelimiter
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
 	"github.com/project-flogo/core/activity"
 	"github.com/project-flogo/core/data"
 	"github.com/project-flogo/core/data/mapper"
@@ -11,6 +14,7 @@ import (
 	logger "github.com/project-flogo/core/support/log"
 	"github.com/project-flogo/core/support/trace"
 	"github.com/stretchr/testify/assert"
+	"github.com/ulule/limiter"
 )
 
 type initContext struct {
@@ -148,6 +152,227 @@ func TestRatelimiter(t *testing.T) {
 	assert.False(t, ctx.output["limitReached"].(bool), "limit should not be reached")
 }
 
+func TestRatelimiterHeaders(t *testing.T) {
+	activity, err := New(newInitContext(map[string]interface{}{
+		"limit": "2-H",
+	}))
+	assert.Nil(t, err)
+
+	ctx := newActivityContext(map[string]interface{}{
+		"token": "abc123",
+	})
+	_, err = activity.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), ctx.output["limit"])
+	assert.Equal(t, int64(1), ctx.output["limitAvailable"])
+	reset := ctx.output["limitReset"].(int64)
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), reset, 1)
+	headers := ctx.output["headers"].(map[string]string)
+	assert.Equal(t, "2", headers["RateLimit-Limit"])
+	assert.Equal(t, "1", headers["RateLimit-Remaining"])
+	assert.NotContains(t, headers, "Retry-After")
+
+	for i := 0; i < 2; i++ {
+		ctx = newActivityContext(map[string]interface{}{
+			"token": "abc123",
+		})
+		_, err = activity.Eval(ctx)
+		assert.Nil(t, err)
+	}
+	assert.True(t, ctx.output["limitReached"].(bool), "limit should be reached")
+	assert.Equal(t, reset, ctx.output["limitReset"])
+	headers = ctx.output["headers"].(map[string]string)
+	assert.Equal(t, "0", headers["RateLimit-Remaining"])
+	assert.Equal(t, headers["RateLimit-Reset"], headers["Retry-After"])
+}
+
+func TestHeaders(t *testing.T) {
+	now := time.Unix(1000, 0)
+	headers := Headers(limiter.Context{
+		Limit:     10,
+		Remaining: 3,
+		Reset:     1030,
+	}, now)
+	assert.Equal(t, map[string]string{
+		"RateLimit-Limit":     "10",
+		"RateLimit-Remaining": "3",
+		"RateLimit-Reset":     "30",
+	}, headers)
+
+	headers = Headers(limiter.Context{
+		Limit:   10,
+		Reset:   1000,
+		Reached: true,
+	}, now)
+	assert.Equal(t, map[string]string{
+		"RateLimit-Limit":     "10",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "0",
+		"Retry-After":         "1",
+	}, headers)
+}
+
+func TestRatelimiterTiers(t *testing.T) {
+	activity, err := New(newInitContext(map[string]interface{}{
+		"tiers": map[string]interface{}{
+			"free": "1-M,2-D",
+			"pro":  "3-S,1000-D",
+		},
+	}))
+	assert.Nil(t, err)
+
+	eval := func(token, tier, limit string) map[string]interface{} {
+		ctx := newActivityContext(map[string]interface{}{
+			"token": token,
+			"tier":  tier,
+			"limit": limit,
+		})
+		_, err := activity.Eval(ctx)
+		assert.Nil(t, err)
+		return ctx.output
+	}
+
+	output := eval("sally", "free", "")
+	assert.False(t, output["limitReached"].(bool), "limit should not be reached")
+	assert.Equal(t, int64(1), output["limit"])
+	output = eval("sally", "free", "")
+	assert.True(t, output["limitReached"].(bool), "limit should be reached")
+	assert.Equal(t, int64(1), output["limit"])
+
+	for i := 0; i < 3; i++ {
+		output = eval("bob", "pro", "")
+		assert.False(t, output["limitReached"].(bool), "limit should not be reached")
+		assert.Equal(t, int64(3), output["limit"])
+		assert.Equal(t, int64(2-i), output["limitAvailable"])
+	}
+	output = eval("bob", "pro", "")
+	assert.True(t, output["limitReached"].(bool), "limit should be reached")
+
+	output = eval("alice", "", "1-H")
+	assert.False(t, output["limitReached"].(bool), "limit should not be reached")
+	assert.Equal(t, int64(1), output["limit"])
+	for i := 0; i < 10; i++ {
+		eval("alice", "", fmt.Sprintf("%d-H", i+2))
+	}
+	assert.Len(t, activity.(*Activity).rates, 2, "only the configured limits should be kept")
+
+	output = eval("alice", "enterprise", "")
+	assert.True(t, output["error"].(bool), "tier should be unknown")
+
+	output = eval("carol", "free", "")
+	assert.Equal(t, int64(0), output["limitAvailable"])
+	output = eval("carol", "", "5-M")
+	assert.False(t, output["limitReached"].(bool), "limits should not share counters")
+	assert.Equal(t, int64(4), output["limitAvailable"])
+
+	output = eval("alice", "", "1-W")
+	assert.True(t, output["error"].(bool), "limit should be invalid")
+
+	activity, err = New(newInitContext(map[string]interface{}{
+		"limit": "1-H",
+		"tiers": map[string]interface{}{
+			"pro": "3-H",
+		},
+	}))
+	assert.Nil(t, err)
+	output = eval("alice", "enterprise", "")
+	assert.False(t, output["error"].(bool), "unknown tiers should get the limit setting")
+	assert.Equal(t, int64(1), output["limit"])
+	output = eval("alice", "", "")
+	assert.True(t, output["limitReached"].(bool), "unknown tiers should share the limit of requests without a tier")
+
+	_, err = New(newInitContext(map[string]interface{}{}))
+	assert.NotNil(t, err)
+	_, err = New(newInitContext(map[string]interface{}{
+		"limit": "1-S",
+		"tiers": map[string]interface{}{
+			"free": "1",
+		},
+	}))
+	assert.NotNil(t, err)
+}
+
+func TestRatelimiterCost(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer server.Close()
+
+	test := func(settings map[string]interface{}) {
+		settings["limit"] = "10-M"
+		activity, err := New(newInitContext(settings))
+		assert.Nil(t, err)
+
+		eval := func(operation string, cost int) map[string]interface{} {
+			ctx := newActivityContext(map[string]interface{}{
+				"token":     "abc123",
+				"operation": operation,
+				"cost":      cost,
+			})
+			_, err := activity.Eval(ctx)
+			assert.Nil(t, err)
+			return ctx.output
+		}
+
+		output := eval("", 0)
+		assert.False(t, output["limitReached"].(bool), "limit should not be reached")
+		assert.Equal(t, int64(9), output["limitAvailable"])
+
+		output = eval("consume", 6)
+		assert.False(t, output["limitReached"].(bool), "limit should not be reached")
+		assert.Equal(t, int64(3), output["limitAvailable"])
+
+		output = eval("consume", 4)
+		assert.True(t, output["limitReached"].(bool), "limit should be reached")
+		assert.Equal(t, int64(0), output["limitAvailable"])
+
+		output = eval("refund", 4)
+		assert.False(t, output["limitReached"].(bool), "limit should not be reached")
+		assert.Equal(t, int64(3), output["limitAvailable"])
+
+		output = eval("refund", 100)
+		assert.Equal(t, int64(10), output["limitAvailable"])
+
+		output = eval("consume", 10)
+		assert.False(t, output["limitReached"].(bool), "limit should not be reached")
+		assert.Equal(t, int64(0), output["limitAvailable"])
+
+		output = eval("consume", -1)
+		assert.True(t, output["error"].(bool), "cost should be invalid")
+	}
+	test(map[string]interface{}{})
+	test(map[string]interface{}{
+		"store":    "redis",
+		"storeUrl": "redis://" + server.Addr(),
+	})
+
+	store := NewMemoryStore(DefaultStorePrefix, time.Minute)
+	count, _, err := store.Increment("abc123", -1, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+	assert.Empty(t, store.counters)
+
+	redisStore, err := NewStore(StoreRedis, "redis://"+server.Addr(), "refund", time.Minute)
+	assert.Nil(t, err)
+	count, _, err = redisStore.Increment("abc123", -1, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+	assert.False(t, server.Exists("refund:abc123"))
+}
+
+func TestParseLimit(t *testing.T) {
+	rates, err := ParseLimit("10-S, 10000-d")
+	assert.Nil(t, err)
+	assert.Equal(t, []limiter.Rate{
+		{Formatted: "10-S", Period: time.Second, Limit: 10},
+		{Formatted: "10000-d", Period: 24 * time.Hour, Limit: 10000},
+	}, rates)
+
+	for _, limit := range []string{"", "10", "10-S-M", "a-S", "-1-S", "10-W", "5-S,10-s"} {
+		_, err = ParseLimit(limit)
+		assert.NotNil(t, err, limit)
+	}
+}
+
 func TestSmartRatelimiter(t *testing.T) {
 	activity, err := New(newInitContext(map[string]interface{}{
 		"limit":          "1000-S",
@@ -198,3 +423,153 @@ func TestSmartRatelimiter(t *testing.T) {
 		return notBlocked > 0
 	}, "some requests should not have been blocked")
 }
+
+func TestRatelimiterRedis(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer server.Close()
+
+	settings := map[string]interface{}{
+		"limit":          "2-M",
+		"spikeThreshold": "2",
+		"store":          "redis",
+		"storeUrl":       "redis://" + server.Addr(),
+		"storePrefix":    "test",
+	}
+	replicaA, err := New(newInitContext(settings))
+	assert.Nil(t, err)
+	replicaB, err := New(newInitContext(settings))
+	assert.Nil(t, err)
+
+	ctx := newActivityContext(map[string]interface{}{
+		"token": "abc123",
+	})
+	_, err = replicaA.Eval(ctx)
+	assert.Nil(t, err)
+	assert.False(t, ctx.output["limitReached"].(bool), "limit should not be reached")
+	assert.Equal(t, int64(1), ctx.output["limitAvailable"])
+
+	ctx = newActivityContext(map[string]interface{}{
+		"token": "abc123",
+	})
+	_, err = replicaB.Eval(ctx)
+	assert.Nil(t, err)
+	assert.False(t, ctx.output["limitReached"].(bool), "limit should not be reached")
+	assert.Equal(t, int64(0), ctx.output["limitAvailable"])
+
+	ctx = newActivityContext(map[string]interface{}{
+		"token": "abc123",
+	})
+	_, err = replicaA.Eval(ctx)
+	assert.Nil(t, err)
+	assert.True(t, ctx.output["limitReached"].(bool), "limit should be reached")
+	assert.True(t, server.Exists("test:abc123:2:60"))
+	assert.True(t, server.Exists("test:spike:abc123"))
+
+	server.FastForward(time.Minute)
+
+	ctx = newActivityContext(map[string]interface{}{
+		"token": "abc123",
+	})
+	_, err = replicaB.Eval(ctx)
+	assert.Nil(t, err)
+	assert.False(t, ctx.output["limitReached"].(bool), "limit should not be reached")
+
+	settings["failClosed"] = true
+	failClosed, err := New(newInitContext(settings))
+	assert.Nil(t, err)
+
+	server.Close()
+	ctx = newActivityContext(map[string]interface{}{
+		"token": "abc123",
+	})
+	_, err = replicaA.Eval(ctx)
+	assert.Nil(t, err)
+	assert.True(t, ctx.output["error"].(bool), "store should be unavailable")
+	assert.False(t, ctx.output["limitReached"].(bool), "the limiter should fail open by default")
+
+	ctx = newActivityContext(map[string]interface{}{
+		"token": "abc123",
+	})
+	_, err = failClosed.Eval(ctx)
+	assert.Nil(t, err)
+	assert.True(t, ctx.output["error"].(bool), "store should be unavailable")
+	assert.True(t, ctx.output["limitReached"].(bool), "the limiter should fail closed")
+}
+
+func TestRedisStoreContention(t *testing.T) {
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer server.Close()
+
+	store, err := NewStore(StoreRedis, "redis://"+server.Addr(), "test", time.Minute)
+	assert.Nil(t, err)
+	contended := &contendedStore{
+		RedisStore: store.(*RedisStore),
+		other:      redis.NewClient(&redis.Options{Addr: server.Addr()}),
+	}
+	defer contended.other.Close()
+
+	err = contended.Update("abc123", func(context *Context) {})
+	assert.Equal(t, ErrorStoreRetry, err)
+	assert.Equal(t, StoreMaxRetry, contended.attempts)
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"limit":          "100-M",
+		"spikeThreshold": "2",
+		"failClosed":     true,
+	}))
+	assert.Nil(t, err)
+	act.(*Activity).store = contended
+	ctx := newActivityContext(map[string]interface{}{
+		"token": "abc123",
+	})
+	_, err = act.Eval(ctx)
+	assert.Nil(t, err)
+	assert.True(t, ctx.output["error"].(bool))
+	assert.Equal(t, ErrorStoreRetry.Error(), ctx.output["errorMessage"])
+	assert.True(t, ctx.output["limitReached"].(bool), "the limiter should fail closed")
+}
+
+// contendedStore is a redis store where another client changes every context while it's updated
+type contendedStore struct {
+	*RedisStore
+	other    *redis.Client
+	attempts int
+}
+
+func (c *contendedStore) Update(key string, update func(context *Context)) error {
+	return c.RedisStore.Update(key, func(context *Context) {
+		c.attempts++
+		other := NewContext()
+		data, _ := other.MarshalBinary()
+		c.other.Set(c.prefix+":spike:"+key, data, 0)
+		update(context)
+	})
+}
+
+func TestContextMarshal(t *testing.T) {
+	store := NewMemoryStore(DefaultStorePrefix, time.Minute)
+	for i := 0; i < MemorySize+8; i++ {
+		err := store.Update("abc123", func(context *Context) {
+			context.memory[context.index] = int64(i)
+			context.index, context.prev = (context.index+1)%MemorySize, context.index
+			if context.size < MemorySize {
+				context.size++
+			}
+			context.filter += .5
+		})
+		assert.Nil(t, err)
+	}
+
+	context := store.contexts["abc123"].Context
+	data, err := context.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := Context{}
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, context, decoded)
+
+	err = decoded.UnmarshalBinary(data[1:])
+	assert.NotNil(t, err)
+}
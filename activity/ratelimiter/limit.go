@@ -0,0 +1,94 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ulule/limiter"
+	"github.com/ulule/limiter/drivers/store/common"
+)
+
+// Periods are the valid limit periods
+var Periods = map[string]time.Duration{
+	"S": time.Second,
+	"M": time.Minute,
+	"H": time.Hour,
+	"D": 24 * time.Hour,
+}
+
+// ParseLimit parses a comma separated list of limits in the format "<limit>-<period>"
+// Example: "10-S,10000-D" is 10 requests / second and 10000 requests / day
+// Each period can only be given once
+func ParseLimit(limit string) ([]limiter.Rate, error) {
+	windows := strings.Split(limit, ",")
+	rates := make([]limiter.Rate, 0, len(windows))
+	periods := make(map[time.Duration]bool, len(windows))
+	for _, window := range windows {
+		window = strings.TrimSpace(window)
+		values := strings.Split(window, "-")
+		if len(values) != 2 {
+			return nil, fmt.Errorf("incorrect limit format '%s'", window)
+		}
+		count, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("incorrect limit '%s'", values[0])
+		}
+		period, ok := Periods[strings.ToUpper(values[1])]
+		if !ok {
+			return nil, fmt.Errorf("incorrect period '%s'", values[1])
+		}
+		if periods[period] {
+			return nil, fmt.Errorf("duplicate period '%s'", values[1])
+		}
+		periods[period] = true
+		rates = append(rates, limiter.Rate{
+			Formatted: window,
+			Period:    period,
+			Limit:     count,
+		})
+	}
+	return rates, nil
+}
+
+// getRates returns the limits for a request, only the configured limits are kept so
+// limits from the input are parsed for every request. Requests with an empty or unknown
+// tier get the limit setting, and are errors without it
+func (a *Activity) getRates(input *Input) ([]limiter.Rate, error) {
+	limit := input.Limit
+	if limit == "" {
+		limit = a.tiers[input.Tier]
+	}
+	if limit == "" {
+		limit = a.limit
+	}
+	if limit == "" {
+		return nil, fmt.Errorf("no limit for tier '%s'", input.Tier)
+	}
+
+	if rates, ok := a.rates[limit]; ok {
+		return rates, nil
+	}
+	return ParseLimit(limit)
+}
+
+// consume consumes cost units of the limits for a token and returns the most restrictive limiter context.
+// Each limit has its own counter, so tokens moving between tiers or limits don't share counters
+func (a *Activity) consume(token string, rates []limiter.Rate, cost int64, now time.Time) (limiter.Context, error) {
+	var result limiter.Context
+	for i, rate := range rates {
+		key := fmt.Sprintf("%s:%d:%d", token, rate.Limit, int64(rate.Period/time.Second))
+		count, expiration, err := a.store.Increment(key, cost, rate.Period)
+		if err != nil {
+			return result, err
+		}
+		context := common.GetContextFromState(now, rate, expiration, count)
+		if i == 0 ||
+			(context.Reached && !result.Reached) ||
+			(context.Reached == result.Reached && context.Remaining < result.Remaining) {
+			result = context
+		}
+	}
+	return result, nil
+}
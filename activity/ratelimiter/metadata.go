@@ -6,14 +6,24 @@ import (
 
 // Settings are the settings for the rate limiter
 type Settings struct {
-	Limit          string  `md:"limit,required"`
-	SpikeThreshold float64 `md:"spikeThreshold"`
-	DecayRate      float64 `md:"decayRate"`
+	Limit          string            `md:"limit"`
+	Tiers          map[string]string `md:"tiers"`
+	SpikeThreshold float64           `md:"spikeThreshold"`
+	DecayRate      float64           `md:"decayRate"`
+	Store          string            `md:"store,allowed(memory,redis)"`
+	StoreURL       string            `md:"storeUrl"`
+	StorePrefix    string            `md:"storePrefix"`
+	StoreTTL       int               `md:"storeTTL"`
+	FailClosed     bool              `md:"failClosed"`
 }
 
 // Input is the input for the rate limiter
 type Input struct {
-	Token string `md:"token,required"`
+	Token     string `md:"token,required"`
+	Tier      string `md:"tier"`
+	Limit     string `md:"limit"`
+	Cost      int    `md:"cost"`
+	Operation string `md:"operation,allowed(consume,refund)"`
 }
 
 // FromMap converts the settings from a map of settings
@@ -23,22 +33,49 @@ func (r *Input) FromMap(values map[string]interface{}) error {
 		return err
 	}
 	r.Token = token
+	tier, err := coerce.ToString(values["tier"])
+	if err != nil {
+		return err
+	}
+	r.Tier = tier
+	limit, err := coerce.ToString(values["limit"])
+	if err != nil {
+		return err
+	}
+	r.Limit = limit
+	cost, err := coerce.ToInt(values["cost"])
+	if err != nil {
+		return err
+	}
+	r.Cost = cost
+	operation, err := coerce.ToString(values["operation"])
+	if err != nil {
+		return err
+	}
+	r.Operation = operation
 	return nil
 }
 
 // ToMap converts the settings to a map from a struct
 func (r *Input) ToMap() map[string]interface{} {
 	return map[string]interface{}{
-		"token": r.Token,
+		"token":     r.Token,
+		"tier":      r.Tier,
+		"limit":     r.Limit,
+		"cost":      r.Cost,
+		"operation": r.Operation,
 	}
 }
 
 // Output is the output of the rate limiter
 type Output struct {
-	LimitReached   bool   `md:"limitReached"`
-	LimitAvailable int64  `md:"limitAvailable"`
-	Error          bool   `md:"error"`
-	ErrorMessage   string `md:"errorMessage"`
+	LimitReached   bool              `md:"limitReached"`
+	LimitAvailable int64             `md:"limitAvailable"`
+	Limit          int64             `md:"limit"`
+	LimitReset     int64             `md:"limitReset"`
+	Headers        map[string]string `md:"headers"`
+	Error          bool              `md:"error"`
+	ErrorMessage   string            `md:"errorMessage"`
 }
 
 // FromMap converts the output from a map to a struct
@@ -53,6 +90,21 @@ func (o *Output) FromMap(values map[string]interface{}) error {
 		return err
 	}
 	o.LimitAvailable = limitAvailable
+	limit, err := coerce.ToInt64(values["limit"])
+	if err != nil {
+		return err
+	}
+	o.Limit = limit
+	limitReset, err := coerce.ToInt64(values["limitReset"])
+	if err != nil {
+		return err
+	}
+	o.LimitReset = limitReset
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.Headers = headers
 	hasError, err := coerce.ToBool(values["error"])
 	if err != nil {
 		return err
@@ -71,6 +123,9 @@ func (o *Output) ToMap() map[string]interface{} {
 	return map[string]interface{}{
 		"limitReached":   o.LimitReached,
 		"limitAvailable": o.LimitAvailable,
+		"limit":          o.Limit,
+		"limitReset":     o.LimitReset,
+		"headers":        o.Headers,
 		"error":          o.Error,
 		"errorMessage":   o.ErrorMessage,
 	}
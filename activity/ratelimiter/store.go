@@ -0,0 +1,297 @@
+package ratelimiter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	// StoreMemory keeps the rate limiter state in process memory
+	StoreMemory = "memory"
+	// StoreRedis keeps the rate limiter state in a Redis compatible server
+	StoreRedis = "redis"
+	// DefaultStorePrefix is the default prefix for keys in the store
+	DefaultStorePrefix = "flogo:ratelimiter"
+	// DefaultStoreTTL is the default number of seconds that idle keys are kept
+	DefaultStoreTTL = 3600
+	// StoreMaxRetry is the maximum number of optimistic locking retries
+	StoreMaxRetry = 16
+)
+
+var (
+	// ErrorStoreRetry happens when a context can't be updated due to contention
+	ErrorStoreRetry = errors.New("retry limit exceeded updating the store")
+)
+
+// Store holds the rate limiter state, possibly shared between gateway replicas
+type Store interface {
+	// Increment adds value to the counter for key, the counter expires after period.
+	// A negative value refunds units: the counter is never created or decreased below zero by a refund
+	Increment(key string, value int64, period time.Duration) (count int64, expiration time.Time, err error)
+	// Update atomically updates the spike detection context for key
+	Update(key string, update func(context *Context)) error
+}
+
+// NewStore creates a new store of the given kind
+func NewStore(kind, url, prefix string, ttl time.Duration) (Store, error) {
+	if prefix == "" {
+		prefix = DefaultStorePrefix
+	}
+	if ttl <= 0 {
+		ttl = DefaultStoreTTL * time.Second
+	}
+	switch kind {
+	case "", StoreMemory:
+		return NewMemoryStore(prefix, ttl), nil
+	case StoreRedis:
+		if url == "" {
+			return nil, errors.New("storeUrl is required for the redis store")
+		}
+		options, err := redis.ParseURL(url)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisStore(redis.NewClient(options), prefix, ttl)
+	}
+	return nil, fmt.Errorf("unknown store: %s", kind)
+}
+
+// MemoryStore is a store local to this process
+type MemoryStore struct {
+	prefix string
+	ttl    time.Duration
+
+	countersLock sync.Mutex
+	counters     map[string]*memoryCounter
+	clean        time.Time
+
+	sync.RWMutex
+	contexts map[string]*memoryContext
+}
+
+type memoryCounter struct {
+	count      int64
+	expiration time.Time
+}
+
+type memoryContext struct {
+	sync.Mutex
+	Context
+}
+
+// NewMemoryStore creates a new memory store
+func NewMemoryStore(prefix string, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		prefix:   prefix,
+		ttl:      ttl,
+		counters: make(map[string]*memoryCounter, 256),
+		clean:    time.Now().Add(ttl),
+		contexts: make(map[string]*memoryContext, 256),
+	}
+}
+
+// Increment adds value to the counter for key
+func (m *MemoryStore) Increment(key string, value int64, period time.Duration) (int64, time.Time, error) {
+	key, now := m.prefix+":"+key, time.Now()
+	m.countersLock.Lock()
+	defer m.countersLock.Unlock()
+
+	if now.After(m.clean) {
+		for k, counter := range m.counters {
+			if now.After(counter.expiration) {
+				delete(m.counters, k)
+			}
+		}
+		m.clean = now.Add(m.ttl)
+	}
+
+	counter := m.counters[key]
+	if counter == nil || now.After(counter.expiration) {
+		if value < 0 {
+			return 0, now, nil
+		}
+		counter = &memoryCounter{
+			expiration: now.Add(period),
+		}
+		m.counters[key] = counter
+	}
+	counter.count += value
+	if counter.count < 0 {
+		counter.count = 0
+	}
+	return counter.count, counter.expiration, nil
+}
+
+// Update updates the spike detection context for key
+func (m *MemoryStore) Update(key string, update func(context *Context)) error {
+	m.RLock()
+	context := m.contexts[key]
+	m.RUnlock()
+	if context == nil {
+		m.Lock()
+		context = m.contexts[key]
+		if context == nil {
+			context = &memoryContext{
+				Context: NewContext(),
+			}
+			m.contexts[key] = context
+		}
+		m.Unlock()
+	}
+
+	context.Lock()
+	update(&context.Context)
+	context.Unlock()
+	return nil
+}
+
+// incrementScript atomically increments a counter and sets the expiration of a new counter
+var incrementScript = redis.NewScript(`
+local value = tonumber(ARGV[1])
+if value < 0 and redis.call("EXISTS", KEYS[1]) == 0 then
+	return {0, 0}
+end
+local count = redis.call("INCRBY", KEYS[1], value)
+if count < 0 then
+	count = redis.call("INCRBY", KEYS[1], -count)
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	ttl = tonumber(ARGV[2])
+end
+return {count, ttl}
+`)
+
+// RedisStore is a store backed by a Redis compatible server
+type RedisStore struct {
+	prefix string
+	ttl    time.Duration
+	client *redis.Client
+}
+
+// NewRedisStore creates a new redis store
+func NewRedisStore(client *redis.Client, prefix string, ttl time.Duration) (*RedisStore, error) {
+	err := client.Ping().Err()
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{
+		prefix: prefix,
+		ttl:    ttl,
+		client: client,
+	}, nil
+}
+
+// Increment adds value to the counter for key
+func (r *RedisStore) Increment(key string, value int64, period time.Duration) (int64, time.Time, error) {
+	now := time.Now()
+	result, err := incrementScript.Run(r.client, []string{r.prefix + ":" + key},
+		value, int64(period/time.Millisecond)).Result()
+	if err != nil {
+		return 0, now, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, now, fmt.Errorf("invalid response from store: %v", result)
+	}
+	count, ok := values[0].(int64)
+	if !ok {
+		return 0, now, fmt.Errorf("invalid count from store: %v", values[0])
+	}
+	ttl, ok := values[1].(int64)
+	if !ok {
+		return 0, now, fmt.Errorf("invalid ttl from store: %v", values[1])
+	}
+	return count, now.Add(time.Duration(ttl) * time.Millisecond), nil
+}
+
+// Update updates the spike detection context for key using optimistic locking
+func (r *RedisStore) Update(key string, update func(context *Context)) error {
+	key = r.prefix + ":spike:" + key
+	transaction := func(tx *redis.Tx) error {
+		context := NewContext()
+		data, err := tx.Get(key).Bytes()
+		if err != nil && err != redis.Nil {
+			return err
+		} else if err == nil {
+			err = context.UnmarshalBinary(data)
+			if err != nil {
+				return err
+			}
+		}
+
+		update(&context)
+
+		data, err = context.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Set(key, data, r.ttl)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < StoreMaxRetry; i++ {
+		err := r.client.Watch(transaction, key)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return ErrorStoreRetry
+}
+
+// contextState is the serialized form of a Context
+type contextState struct {
+	Index, Prev, Size int64
+	LastSpike         int64
+	Filter, LastRatio float64
+	Memory            [MemorySize]int64
+}
+
+// contextStateSize is the size in bytes of a serialized Context
+var contextStateSize = binary.Size(contextState{})
+
+// MarshalBinary serializes the context
+func (c *Context) MarshalBinary() ([]byte, error) {
+	state := contextState{
+		Index:     int64(c.index),
+		Prev:      int64(c.prev),
+		Size:      int64(c.size),
+		LastSpike: c.lastSpike,
+		Filter:    c.filter,
+		LastRatio: c.lastRatio,
+		Memory:    c.memory,
+	}
+	buffer := bytes.NewBuffer(make([]byte, 0, contextStateSize))
+	err := binary.Write(buffer, binary.LittleEndian, &state)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary deserializes the context
+func (c *Context) UnmarshalBinary(data []byte) error {
+	if len(data) != contextStateSize {
+		return fmt.Errorf("invalid context size: %d", len(data))
+	}
+	state := contextState{}
+	err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &state)
+	if err != nil {
+		return err
+	}
+	c.index, c.prev, c.size = int(state.Index), int(state.Prev), int(state.Size)
+	c.lastSpike = state.LastSpike
+	c.filter, c.lastRatio = state.Filter, state.LastRatio
+	c.memory = state.Memory
+	return nil
+}
@@ -0,0 +1,261 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/coerce"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+const (
+	// DefaultSignatureHeader is the default header holding the signature
+	DefaultSignatureHeader = "X-Signature"
+	// DefaultTimestampHeader is the default header holding the timestamp
+	DefaultTimestampHeader = "X-Timestamp"
+	// DefaultTolerance is the default number of seconds a timestamp can differ from the current time
+	DefaultTolerance = 300
+)
+
+var (
+	// ErrorMissingSignature happens when the request has no signature
+	ErrorMissingSignature = errors.New("missing signature")
+	// ErrorMissingTimestamp happens when the request has no timestamp
+	ErrorMissingTimestamp = errors.New("missing timestamp")
+	// ErrorInvalidTimestamp happens when the timestamp isn't a number of seconds since the epoch
+	ErrorInvalidTimestamp = errors.New("invalid timestamp")
+	// ErrorTimestampTolerance happens when the timestamp is too far from the current time
+	ErrorTimestampTolerance = errors.New("timestamp outside of the tolerance")
+	// ErrorMissingNonce happens when the request has no nonce
+	ErrorMissingNonce = errors.New("missing nonce")
+	// ErrorReplay happens when a nonce has already been used
+	ErrorReplay = errors.New("request has already been received")
+	// ErrorInvalidSignature happens when the signature doesn't match
+	ErrorInvalidSignature = errors.New("invalid signature")
+	// ErrorDecodedContent happens when the content isn't the raw body of the request
+	ErrorDecodedContent = errors.New("content must be the raw request body")
+	activityMetadata    = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+var algorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new signature verifier
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := settings.Algorithm
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	headers := make([]string, 0, len(settings.Headers))
+	for _, header := range settings.Headers {
+		name, err := coerce.ToString(header)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, name)
+	}
+
+	act := &Activity{
+		secret:          []byte(settings.Secret),
+		hash:            algorithms[algorithm],
+		base64:          settings.Encoding == "base64",
+		prefix:          settings.Prefix,
+		signatureHeader: settings.SignatureHeader,
+		timestampHeader: settings.TimestampHeader,
+		nonceHeader:     settings.NonceHeader,
+		headers:         headers,
+		tolerance:       time.Duration(settings.Tolerance) * time.Second,
+		nonces:          make(map[string]time.Time, 256),
+	}
+	if act.signatureHeader == "" {
+		act.signatureHeader = DefaultSignatureHeader
+	}
+	if act.timestampHeader == "" {
+		act.timestampHeader = DefaultTimestampHeader
+	}
+	if act.tolerance <= 0 {
+		act.tolerance = DefaultTolerance * time.Second
+	}
+	return act, nil
+}
+
+// Activity is an HMAC request signature verifier
+type Activity struct {
+	secret          []byte
+	hash            func() hash.Hash
+	base64          bool
+	prefix          string
+	signatureHeader string
+	timestampHeader string
+	nonceHeader     string
+	headers         []string
+	tolerance       time.Duration
+
+	sync.Mutex
+	nonces map[string]time.Time
+	clean  time.Time
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := Output{}
+	content, err := toBytes(input.Content)
+	if err != nil {
+		output.Error = true
+		output.ErrorMessage = err.Error()
+	} else if err = a.verify(content, input.Headers, time.Now()); err != nil {
+		output.ValidationMessage = err.Error()
+	} else {
+		output.Valid = true
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// verify verifies the signature of a request
+func (a *Activity) verify(content []byte, headers map[string]string, now time.Time) error {
+	signature := header(headers, a.signatureHeader)
+	if signature == "" {
+		return ErrorMissingSignature
+	}
+	timestamp := header(headers, a.timestampHeader)
+	if timestamp == "" {
+		return ErrorMissingTimestamp
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrorInvalidTimestamp
+	}
+	if delta := now.Sub(time.Unix(seconds, 0)); delta > a.tolerance || delta < -a.tolerance {
+		return ErrorTimestampTolerance
+	}
+	var nonce string
+	if a.nonceHeader != "" {
+		nonce = header(headers, a.nonceHeader)
+		if nonce == "" {
+			return ErrorMissingNonce
+		}
+	}
+
+	mac := hmac.New(a.hash, a.secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'\n'})
+	if a.nonceHeader != "" {
+		mac.Write([]byte(nonce))
+		mac.Write([]byte{'\n'})
+	}
+	for _, name := range a.headers {
+		mac.Write([]byte(header(headers, name)))
+		mac.Write([]byte{'\n'})
+	}
+	mac.Write(content)
+	expected := mac.Sum(nil)
+
+	signature = strings.TrimSpace(signature)
+	if a.prefix != "" {
+		signature = strings.TrimPrefix(signature, a.prefix)
+	}
+	var actual []byte
+	if a.base64 {
+		actual, err = base64.StdEncoding.DecodeString(signature)
+	} else {
+		actual, err = hex.DecodeString(signature)
+	}
+	if err != nil || !hmac.Equal(expected, actual) {
+		return ErrorInvalidSignature
+	}
+
+	// Signatures are unique per request when there is no nonce
+	if nonce == "" {
+		nonce = string(expected)
+	}
+	if !a.remember(nonce, time.Unix(seconds, 0).Add(a.tolerance), now) {
+		return ErrorReplay
+	}
+	return nil
+}
+
+// remember adds a nonce to the replay cache until expiration, returning false if it has already been seen
+func (a *Activity) remember(nonce string, expiration, now time.Time) bool {
+	a.Lock()
+	defer a.Unlock()
+	if now.After(a.clean) {
+		for key, value := range a.nonces {
+			if now.After(value) {
+				delete(a.nonces, key)
+			}
+		}
+		a.clean = now.Add(a.tolerance)
+	}
+	if seen, ok := a.nonces[nonce]; ok && !now.After(seen) {
+		return false
+	}
+	a.nonces[nonce] = expiration
+	return true
+}
+
+// header returns the value of a header, matching the name case insensitively
+func header(headers map[string]string, name string) string {
+	if value, ok := headers[name]; ok {
+		return value
+	}
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// toBytes returns the raw body of a request, decoded content can't be verified because
+// encoding it again doesn't give back the bytes which were signed
+func toBytes(content interface{}) ([]byte, error) {
+	switch content := content.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(content), nil
+	case []byte:
+		return content, nil
+	}
+	return nil, ErrorDecodedContent
+}
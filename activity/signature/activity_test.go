@@ -0,0 +1,280 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func sign(h func() hash.Hash, secret string, parts ...string) []byte {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return mac.Sum(nil)
+}
+
+func verify(t *testing.T, act activity.Activity, content interface{}, headers map[string]string) map[string]interface{} {
+	ctx := newActivityContext(map[string]interface{}{
+		"content": content,
+		"headers": headers,
+	})
+	_, err := act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func TestSignature(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"secret":  "secret",
+		"headers": []interface{}{"X-Event"},
+	}))
+	assert.Nil(t, err)
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	body := `{"id":1,"name":"sam"}`
+	headers := map[string]string{
+		"x-timestamp": now,
+		"X-Event":     "order.created",
+		"X-Signature": hex.EncodeToString(sign(sha256.New, "secret", now, "order.created", body)),
+	}
+	output := verify(t, act, body, headers)
+	assert.True(t, output["valid"].(bool))
+	assert.Equal(t, "", output["validationMessage"])
+
+	output = verify(t, act, body, headers)
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorReplay.Error(), output["validationMessage"])
+
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	tests := []struct {
+		content string
+		headers map[string]string
+		message error
+	}{
+		{body, map[string]string{"X-Timestamp": now, "X-Event": "order.created"}, ErrorMissingSignature},
+		{body, map[string]string{"X-Signature": "00", "X-Event": "order.created"}, ErrorMissingTimestamp},
+		{body, map[string]string{"X-Signature": "00", "X-Timestamp": "yesterday"}, ErrorInvalidTimestamp},
+		{body, map[string]string{
+			"X-Timestamp": old,
+			"X-Event":     "order.created",
+			"X-Signature": hex.EncodeToString(sign(sha256.New, "secret", old, "order.created", body)),
+		}, ErrorTimestampTolerance},
+		{`{"id":2,"name":"sam"}`, map[string]string{
+			"X-Timestamp": now,
+			"X-Event":     "order.created",
+			"X-Signature": hex.EncodeToString(sign(sha256.New, "secret", now, "order.created", `{"id":1,"name":"sam"}`)),
+		}, ErrorInvalidSignature},
+		{body, map[string]string{
+			"X-Timestamp": now,
+			"X-Event":     "order.deleted",
+			"X-Signature": hex.EncodeToString(sign(sha256.New, "secret", now, "order.created", body)),
+		}, ErrorInvalidSignature},
+		{body, map[string]string{
+			"X-Timestamp": now,
+			"X-Event":     "order.created",
+			"X-Signature": hex.EncodeToString(sign(sha256.New, "other", now, "order.created", body)),
+		}, ErrorInvalidSignature},
+		{body, map[string]string{"X-Timestamp": now, "X-Signature": "not hex"}, ErrorInvalidSignature},
+	}
+	for _, test := range tests {
+		output = verify(t, act, test.content, test.headers)
+		assert.False(t, output["valid"].(bool), test.message)
+		assert.Equal(t, test.message.Error(), output["validationMessage"])
+	}
+}
+
+func TestSignatureNonce(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"secret":          "secret",
+		"algorithm":       "sha512",
+		"encoding":        "base64",
+		"prefix":          "sha512=",
+		"signatureHeader": "X-Hub-Signature",
+		"timestampHeader": "X-Hub-Timestamp",
+		"nonceHeader":     "X-Hub-Delivery",
+		"tolerance":       60,
+	}))
+	assert.Nil(t, err)
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	content := `{"id":1,"name":"<sam>"}`
+	request := func(nonce string) map[string]string {
+		return map[string]string{
+			"X-Hub-Timestamp": now,
+			"X-Hub-Delivery":  nonce,
+			"X-Hub-Signature": "sha512=" + base64.StdEncoding.EncodeToString(sign(sha512.New, "secret", now, nonce, content)),
+		}
+	}
+
+	output := verify(t, act, content, request("1"))
+	assert.True(t, output["valid"].(bool))
+	output = verify(t, act, content, request("2"))
+	assert.True(t, output["valid"].(bool))
+	output = verify(t, act, content, request("1"))
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorReplay.Error(), output["validationMessage"])
+
+	headers := request("3")
+	delete(headers, "X-Hub-Delivery")
+	output = verify(t, act, content, headers)
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorMissingNonce.Error(), output["validationMessage"])
+
+	verifier := act.(*Activity)
+	start := time.Now()
+	assert.True(t, verifier.remember("4", start.Add(time.Minute), start))
+	assert.False(t, verifier.remember("4", start.Add(time.Minute), start.Add(30*time.Second)))
+	assert.True(t, verifier.remember("4", start.Add(3*time.Minute), start.Add(2*time.Minute)))
+	verifier.Lock()
+	assert.Len(t, verifier.nonces, 1, "expired nonces should be removed")
+	verifier.Unlock()
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"secret":    "secret",
+		"algorithm": "md5",
+	}))
+	assert.NotNil(t, err)
+}
+
+func TestSignatureRawBody(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"secret": "secret",
+	}))
+	assert.Nil(t, err)
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	body := "{\n  \"name\": \"sam\",\n  \"id\": 1.0\n}"
+	request := func(content string) map[string]string {
+		return map[string]string{
+			"X-Timestamp": now,
+			"X-Signature": hex.EncodeToString(sign(sha256.New, "secret", now, content)),
+		}
+	}
+
+	var decoded interface{}
+	assert.Nil(t, json.Unmarshal([]byte(body), &decoded))
+	output := verify(t, act, decoded, request(body))
+	assert.False(t, output["valid"].(bool), "decoded content can't be verified")
+	assert.True(t, output["error"].(bool))
+	assert.Equal(t, ErrorDecodedContent.Error(), output["errorMessage"])
+	assert.Equal(t, "", output["validationMessage"])
+
+	output = verify(t, act, body, request(body))
+	assert.True(t, output["valid"].(bool), "the raw body should be verified as it was signed")
+	output = verify(t, act, []byte(body), request(body))
+	assert.False(t, output["valid"].(bool))
+	assert.Equal(t, ErrorReplay.Error(), output["validationMessage"])
+}
@@ -0,0 +1,86 @@
+package signature
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the signature verifier
+type Settings struct {
+	Secret          string        `md:"secret,required"`
+	Algorithm       string        `md:"algorithm,allowed(sha1,sha256,sha512)"`
+	Encoding        string        `md:"encoding,allowed(hex,base64)"`
+	Prefix          string        `md:"prefix"`
+	SignatureHeader string        `md:"signatureHeader"`
+	TimestampHeader string        `md:"timestampHeader"`
+	NonceHeader     string        `md:"nonceHeader"`
+	Headers         []interface{} `md:"headers"`
+	Tolerance       int           `md:"tolerance"`
+}
+
+// Input is the input for the signature verifier
+type Input struct {
+	Content interface{}       `md:"content"`
+	Headers map[string]string `md:"headers"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	r.Content = values["content"]
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"content": r.Content,
+		"headers": r.Headers,
+	}
+}
+
+// Output is the output of the signature verifier
+type Output struct {
+	Valid             bool   `md:"valid"`
+	ValidationMessage string `md:"validationMessage"`
+	Error             bool   `md:"error"`
+	ErrorMessage      string `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	valid, err := coerce.ToBool(values["valid"])
+	if err != nil {
+		return err
+	}
+	o.Valid = valid
+	validationMessage, err := coerce.ToString(values["validationMessage"])
+	if err != nil {
+		return err
+	}
+	o.ValidationMessage = validationMessage
+	e, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = e
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"valid":             o.Valid,
+		"validationMessage": o.ValidationMessage,
+		"error":             o.Error,
+		"errorMessage":      o.ErrorMessage,
+	}
+}
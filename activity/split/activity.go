@@ -0,0 +1,102 @@
+package split
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new traffic splitter
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	act := &Activity{
+		salt: settings.Salt,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	switch {
+	case settings.WeightsFile != "" && len(settings.Buckets) > 0:
+		return nil, errors.New("buckets and weightsFile can't both be set")
+	case settings.WeightsFile != "":
+		act.file, err = NewFileBuckets(settings.WeightsFile)
+	case len(settings.Buckets) > 0:
+		act.buckets, err = ParseBuckets(settings.Buckets)
+	default:
+		err = errors.New("buckets or weightsFile are required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return act, nil
+}
+
+// Activity assigns requests to weighted buckets
+type Activity struct {
+	buckets *Buckets
+	file    *FileBuckets
+	salt    string
+
+	sync.Mutex
+	rand *rand.Rand
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	buckets := a.buckets
+	if a.file != nil {
+		buckets = a.file.Buckets()
+	}
+	output := Output{}
+	if input.Key != "" {
+		output.Bucket, output.Sticky = buckets.Assign(Point(a.salt, input.Key)), true
+	} else {
+		a.Lock()
+		point := a.rand.Float64()
+		a.Unlock()
+		output.Bucket = buckets.Assign(point)
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Point hashes a key to a point in [0, 1), the salt gives independent assignments for the same key
+func Point(salt, key string) float64 {
+	hash := sha256.Sum256([]byte(salt + "\x00" + key))
+	return float64(binary.BigEndian.Uint64(hash[:8])>>11) / (1 << 53)
+}
@@ -0,0 +1,229 @@
+package split
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func TestSplit(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"buckets": map[string]interface{}{
+			"stable": 90,
+			"canary": 10,
+		},
+	}))
+	assert.Nil(t, err)
+	eval := func(key string) map[string]interface{} {
+		ctx := newActivityContext(map[string]interface{}{"key": key})
+		_, err := act.Eval(ctx)
+		assert.Nil(t, err)
+		return ctx.output
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("user%d", i)
+		output := eval(key)
+		assert.True(t, output["sticky"].(bool), "assignment should be sticky")
+		bucket := output["bucket"].(string)
+		counts[bucket]++
+		if i < 100 {
+			assert.Equal(t, bucket, eval(key)["bucket"], "key should stay in its bucket")
+		}
+	}
+	assert.InDelta(t, 1000, counts["canary"], 150)
+	assert.InDelta(t, 9000, counts["stable"], 150)
+
+	counts = make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		output := eval("")
+		assert.False(t, output["sticky"].(bool), "assignment should be random")
+		counts[output["bucket"].(string)]++
+	}
+	assert.InDelta(t, 1000, counts["canary"], 150)
+
+	salted, err := New(newInitContext(map[string]interface{}{
+		"buckets": map[string]interface{}{"a": 1, "b": 1},
+		"salt":    "experiment",
+	}))
+	assert.Nil(t, err)
+	assert.NotEqual(t, Point("", "user1"), Point("experiment", "user1"))
+	ctx := newActivityContext(map[string]interface{}{"key": "user1"})
+	_, err = salted.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Contains(t, []string{"a", "b"}, ctx.output["bucket"])
+
+	for _, settings := range []map[string]interface{}{
+		{},
+		{"buckets": map[string]interface{}{"a": 0}},
+		{"buckets": map[string]interface{}{"a": -1, "b": 2}},
+		{"buckets": map[string]interface{}{"a": "x"}},
+		{"buckets": map[string]interface{}{"a": 1}, "weightsFile": "weights.json"},
+	} {
+		_, err := New(newInitContext(settings))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	buckets, err := ParseBuckets(map[string]interface{}{"stable": 80, "canary": 20, "dark": 0})
+	assert.Nil(t, err)
+	assert.Equal(t, "canary", buckets.Assign(0))
+	assert.Equal(t, "canary", buckets.Assign(.19))
+	assert.Equal(t, "stable", buckets.Assign(.2))
+	assert.Equal(t, "stable", buckets.Assign(.9999))
+	assert.Equal(t, map[string]float64{"stable": 80, "canary": 20, "dark": 0}, buckets.Weights())
+
+	wider, err := ParseBuckets(map[string]interface{}{"stable": 50, "canary": 50})
+	assert.Nil(t, err)
+	for i := 0; i < 1000; i++ {
+		point := Point("", fmt.Sprintf("user%d", i))
+		if buckets.Assign(point) == "canary" {
+			assert.Equal(t, "canary", wider.Assign(point), "canary keys should stay in canary")
+		}
+	}
+}
+
+func TestSplitWeightsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "split")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "weights.json")
+	err = ioutil.WriteFile(file, []byte(`{"stable": 100, "canary": 0}`), 0644)
+	assert.Nil(t, err)
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"weightsFile": file,
+	}))
+	assert.Nil(t, err)
+	eval := func() string {
+		ctx := newActivityContext(map[string]interface{}{"key": "user1"})
+		_, err := act.Eval(ctx)
+		assert.Nil(t, err)
+		return ctx.output["bucket"].(string)
+	}
+	assert.Equal(t, "stable", eval())
+
+	update := func(content string) {
+		err := ioutil.WriteFile(file, []byte(content), 0644)
+		assert.Nil(t, err)
+		later := time.Now().Add(time.Minute)
+		err = os.Chtimes(file, later, later)
+		assert.Nil(t, err)
+		act.(*Activity).file.Reload()
+	}
+	update(`{"stable": 0, "canary": 100}`)
+	assert.Equal(t, "canary", eval(), "weights should be reloaded")
+	update(`{"stable": "x"}`)
+	assert.Equal(t, "canary", eval(), "invalid weights should be ignored")
+}
@@ -0,0 +1,103 @@
+package split
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/project-flogo/core/data/coerce"
+	"github.com/project-flogo/microgateway/internal/watch"
+)
+
+// Bucket is a named share of the traffic
+type Bucket struct {
+	Name   string
+	Weight float64
+}
+
+// Buckets are weighted buckets laid out in the alphabetical order of their names
+type Buckets struct {
+	buckets []Bucket
+	total   float64
+}
+
+// ParseBuckets parses a map of bucket names to weights
+func ParseBuckets(weights map[string]interface{}) (*Buckets, error) {
+	buckets := &Buckets{
+		buckets: make([]Bucket, 0, len(weights)),
+	}
+	for name, value := range weights {
+		weight, err := coerce.ToFloat64(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for bucket '%s': %v", name, err)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("negative weight for bucket '%s'", name)
+		}
+		buckets.buckets = append(buckets.buckets, Bucket{Name: name, Weight: weight})
+		buckets.total += weight
+	}
+	if buckets.total <= 0 {
+		return nil, errors.New("at least one bucket with a positive weight is required")
+	}
+	sort.Slice(buckets.buckets, func(i, j int) bool {
+		return buckets.buckets[i].Name < buckets.buckets[j].Name
+	})
+	return buckets, nil
+}
+
+// Assign returns the bucket for a point in [0, 1)
+func (b *Buckets) Assign(point float64) string {
+	target, last := point*b.total, ""
+	for _, bucket := range b.buckets {
+		if bucket.Weight == 0 {
+			continue
+		}
+		if target < bucket.Weight {
+			return bucket.Name
+		}
+		target -= bucket.Weight
+		last = bucket.Name
+	}
+	return last
+}
+
+// Weights returns the buckets as a map of names to weights
+func (b *Buckets) Weights() map[string]float64 {
+	weights := make(map[string]float64, len(b.buckets))
+	for _, bucket := range b.buckets {
+		weights[bucket.Name] = bucket.Weight
+	}
+	return weights
+}
+
+// FileBuckets are buckets loaded from a JSON file mapping bucket names to weights,
+// the file is reloaded when it changes
+type FileBuckets struct {
+	*watch.File
+}
+
+// NewFileBuckets creates new file buckets and loads the file
+func NewFileBuckets(path string) (*FileBuckets, error) {
+	file, err := watch.NewFile(path, "weights file", parseWeightsFile)
+	if err != nil {
+		return nil, err
+	}
+	return &FileBuckets{File: file}, nil
+}
+
+// parseWeightsFile parses a weights file into buckets
+func parseWeightsFile(data []byte) (interface{}, error) {
+	weights := make(map[string]interface{})
+	err := json.Unmarshal(data, &weights)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBuckets(weights)
+}
+
+// Buckets returns the buckets, reloading the file when it has changed
+func (f *FileBuckets) Buckets() *Buckets {
+	return f.Value().(*Buckets)
+}
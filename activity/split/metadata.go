@@ -0,0 +1,63 @@
+package split
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the traffic splitter
+type Settings struct {
+	Buckets     map[string]interface{} `md:"buckets"`
+	WeightsFile string                 `md:"weightsFile"`
+	Salt        string                 `md:"salt"`
+}
+
+// Input is the input for the traffic splitter
+type Input struct {
+	Key string `md:"key"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	key, err := coerce.ToString(values["key"])
+	if err != nil {
+		return err
+	}
+	r.Key = key
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"key": r.Key,
+	}
+}
+
+// Output is the output of the traffic splitter
+type Output struct {
+	Bucket string `md:"bucket"`
+	Sticky bool   `md:"sticky"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	bucket, err := coerce.ToString(values["bucket"])
+	if err != nil {
+		return err
+	}
+	o.Bucket = bucket
+	sticky, err := coerce.ToBool(values["sticky"])
+	if err != nil {
+		return err
+	}
+	o.Sticky = sticky
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"bucket": o.Bucket,
+		"sticky": o.Sticky,
+	}
+}
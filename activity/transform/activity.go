@@ -0,0 +1,67 @@
+package transform
+
+import (
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new transformation
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	transformation, err := NewTransformation(&settings)
+	if err != nil {
+		return nil, err
+	}
+	return &Activity{transformation: transformation}, nil
+}
+
+// Activity transforms requests and responses
+type Activity struct {
+	transformation *Transformation
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	output := Output{}
+	output.Data, err = a.transformation.Transform(input.Data)
+	if err == nil {
+		output.Text, err = a.transformation.Render(output.Data)
+	}
+	if err != nil {
+		output.Error = true
+		output.ErrorMessage = err.Error()
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
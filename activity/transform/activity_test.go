@@ -0,0 +1,256 @@
+package transform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func pets() map[string]interface{} {
+	return map[string]interface{}{
+		"total": 2.0,
+		"items": []interface{}{
+			map[string]interface{}{
+				"id":     1.0,
+				"name":   "sally",
+				"secret": "a",
+				"owner":  map[string]interface{}{"name": "bob", "ssn": "123"},
+			},
+			map[string]interface{}{
+				"id":   2.0,
+				"name": "jake & co",
+				"tags": []interface{}{"dog"},
+			},
+		},
+	}
+}
+
+func TestTransform(t *testing.T) {
+	eval := func(settings map[string]interface{}, data interface{}) map[string]interface{} {
+		activity, err := New(newInitContext(settings))
+		assert.Nil(t, err)
+		ctx := newActivityContext(map[string]interface{}{"data": data})
+		_, err = activity.Eval(ctx)
+		assert.Nil(t, err)
+		return ctx.output
+	}
+
+	input := pets()
+	output := eval(map[string]interface{}{
+		"select":  "$.items[*]",
+		"exclude": []interface{}{"secret", "owner.ssn"},
+		"rename": map[string]interface{}{
+			"owner.name": "ownerName",
+		},
+		"defaults": map[string]interface{}{
+			"tags":      []interface{}{},
+			"ownerName": "unknown",
+		},
+	}, input)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"id":        1.0,
+			"name":      "sally",
+			"owner":     map[string]interface{}{},
+			"ownerName": "bob",
+			"tags":      []interface{}{},
+		},
+		map[string]interface{}{
+			"id":        2.0,
+			"name":      "jake & co",
+			"ownerName": "unknown",
+			"tags":      []interface{}{"dog"},
+		},
+	}, output["data"])
+	assert.Equal(t, pets(), input, "input should not be modified")
+
+	output = eval(map[string]interface{}{
+		"select":  "$.items",
+		"include": []interface{}{"id", "owner.name"},
+	}, input)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": 1.0, "owner": map[string]interface{}{"name": "bob"}},
+		map[string]interface{}{"id": 2.0},
+	}, output["data"])
+
+	output = eval(map[string]interface{}{
+		"select": "$.items[0]",
+		"mapping": map[string]interface{}{
+			"pet.id":    "$.id",
+			"pet.owner": "$.owner.name",
+			"missing":   "$.nothing",
+		},
+	}, input)
+	assert.Equal(t, map[string]interface{}{
+		"pet": map[string]interface{}{"id": 1.0, "owner": "bob"},
+	}, output["data"])
+
+	output = eval(map[string]interface{}{
+		"select": "$.items[?(@.id == 2)].name",
+	}, input)
+	assert.Equal(t, []interface{}{"jake & co"}, output["data"])
+
+	output = eval(map[string]interface{}{
+		"select": "$.nothing",
+	}, input)
+	assert.Nil(t, output["data"])
+	assert.True(t, output["error"].(bool), "select should not match")
+	assert.Contains(t, output["errorMessage"], "unable to select data")
+
+	output = eval(map[string]interface{}{
+		"include": []interface{}{"Content-Type"},
+	}, map[string]string{"Content-Type": "application/json", "Authorization": "Bearer abc"})
+	assert.Equal(t, map[string]interface{}{"Content-Type": "application/json"}, output["data"])
+
+	for _, settings := range []map[string]interface{}{
+		{"select": "items"},
+		{"mapping": map[string]interface{}{"id": "id"}},
+		{"template": "{{.name"},
+		{"template": "a", "templateFile": "a.tmpl"},
+	} {
+		_, err := New(newInitContext(settings))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestTransformTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transform")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "pets.xml")
+	err = ioutil.WriteFile(file, []byte(`<pets>{{range .}}<pet id="{{.id}}">{{xml .name}}</pet>{{end}}</pets>`), 0644)
+	assert.Nil(t, err)
+
+	activity, err := New(newInitContext(map[string]interface{}{
+		"select":       "$.items[*]",
+		"include":      []interface{}{"id", "name"},
+		"templateFile": file,
+	}))
+	assert.Nil(t, err)
+	ctx := newActivityContext(map[string]interface{}{"data": pets()})
+	_, err = activity.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, `<pets><pet id="1">sally</pet><pet id="2">jake &amp; co</pet></pets>`, ctx.output["text"])
+	assert.Len(t, ctx.output["data"], 2)
+
+	activity, err = New(newInitContext(map[string]interface{}{
+		"template": `{"count": {{len .items}}, "first": {{json (index .items 0).name}}}`,
+	}))
+	assert.Nil(t, err)
+	ctx = newActivityContext(map[string]interface{}{"data": pets()})
+	_, err = activity.Eval(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"count": 2, "first": "sally"}`, ctx.output["text"])
+
+	ctx = newActivityContext(map[string]interface{}{"data": "text"})
+	_, err = activity.Eval(ctx)
+	assert.Nil(t, err)
+	assert.True(t, ctx.output["error"].(bool), "template should fail")
+}
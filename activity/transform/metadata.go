@@ -0,0 +1,74 @@
+package transform
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the transformation
+type Settings struct {
+	Select       string                 `md:"select"`
+	Mapping      map[string]string      `md:"mapping"`
+	Include      []interface{}          `md:"include"`
+	Exclude      []interface{}          `md:"exclude"`
+	Rename       map[string]string      `md:"rename"`
+	Defaults     map[string]interface{} `md:"defaults"`
+	Template     string                 `md:"template"`
+	TemplateFile string                 `md:"templateFile"`
+}
+
+// Input is the input for the transformation
+type Input struct {
+	Data interface{} `md:"data"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	r.Data = values["data"]
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data": r.Data,
+	}
+}
+
+// Output is the output of the transformation
+type Output struct {
+	Data         interface{} `md:"data"`
+	Text         string      `md:"text"`
+	Error        bool        `md:"error"`
+	ErrorMessage string      `md:"errorMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	o.Data = values["data"]
+	text, err := coerce.ToString(values["text"])
+	if err != nil {
+		return err
+	}
+	o.Text = text
+	hasError, err := coerce.ToBool(values["error"])
+	if err != nil {
+		return err
+	}
+	o.Error = hasError
+	errorMessage, err := coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	o.ErrorMessage = errorMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data":         o.Data,
+		"text":         o.Text,
+		"error":        o.Error,
+		"errorMessage": o.ErrorMessage,
+	}
+}
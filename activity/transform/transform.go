@@ -0,0 +1,282 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/oliveagle/jsonpath"
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Transformation reshapes a value. The value is selected with a JSONPath, then each object,
+// or each element of a selected array, is mapped, filtered, renamed and completed with defaults
+type Transformation struct {
+	selector *jsonpath.Compiled
+	mapping  []fieldMapping
+	include  [][]string
+	exclude  [][]string
+	rename   []fieldRename
+	defaults []fieldDefault
+	template *template.Template
+}
+
+type fieldMapping struct {
+	path     []string
+	selector *jsonpath.Compiled
+}
+
+type fieldRename struct {
+	from, to []string
+}
+
+type fieldDefault struct {
+	path  []string
+	value interface{}
+}
+
+// templateFunctions are the functions available in templates
+var templateFunctions = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	"xml": func(value interface{}) (string, error) {
+		text, err := coerce.ToString(value)
+		if err != nil {
+			return "", err
+		}
+		buffer := bytes.Buffer{}
+		err = xml.EscapeText(&buffer, []byte(text))
+		return buffer.String(), err
+	},
+}
+
+// NewTransformation creates a new transformation from the settings
+func NewTransformation(settings *Settings) (*Transformation, error) {
+	t := &Transformation{}
+	var err error
+	if settings.Select != "" {
+		t.selector, err = jsonpath.Compile(settings.Select)
+		if err != nil {
+			return nil, fmt.Errorf("invalid select '%s': %v", settings.Select, err)
+		}
+	}
+
+	for _, field := range sortedKeys(settings.Mapping) {
+		selector, err := jsonpath.Compile(settings.Mapping[field])
+		if err != nil {
+			return nil, fmt.Errorf("invalid mapping for '%s': %v", field, err)
+		}
+		t.mapping = append(t.mapping, fieldMapping{
+			path:     splitPath(field),
+			selector: selector,
+		})
+	}
+
+	for _, field := range settings.Include {
+		path, err := coerce.ToString(field)
+		if err != nil {
+			return nil, err
+		}
+		t.include = append(t.include, splitPath(path))
+	}
+	for _, field := range settings.Exclude {
+		path, err := coerce.ToString(field)
+		if err != nil {
+			return nil, err
+		}
+		t.exclude = append(t.exclude, splitPath(path))
+	}
+	for _, field := range sortedKeys(settings.Rename) {
+		t.rename = append(t.rename, fieldRename{
+			from: splitPath(field),
+			to:   splitPath(settings.Rename[field]),
+		})
+	}
+	fields := make([]string, 0, len(settings.Defaults))
+	for field := range settings.Defaults {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		t.defaults = append(t.defaults, fieldDefault{
+			path:  splitPath(field),
+			value: settings.Defaults[field],
+		})
+	}
+
+	text := settings.Template
+	if settings.TemplateFile != "" {
+		if text != "" {
+			return nil, fmt.Errorf("template and templateFile can't both be set")
+		}
+		data, err := ioutil.ReadFile(settings.TemplateFile)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	if text != "" {
+		t.template, err = template.New("transform").Funcs(templateFunctions).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %v", err)
+		}
+	}
+	return t, nil
+}
+
+// Transform transforms a value, the value isn't modified. An error is returned when
+// the select JSONPath doesn't match the value
+func (t *Transformation) Transform(value interface{}) (interface{}, error) {
+	value = copyValue(value)
+	if t.selector != nil {
+		selected, err := t.selector.Lookup(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to select data: %v", err)
+		}
+		value = selected
+	}
+
+	if array, ok := value.([]interface{}); ok {
+		for i, element := range array {
+			array[i] = t.transformElement(element)
+		}
+		return array, nil
+	}
+	return t.transformElement(value), nil
+}
+
+// Render renders the text template with the value, or returns an empty string without a template
+func (t *Transformation) Render(value interface{}) (string, error) {
+	if t.template == nil {
+		return "", nil
+	}
+	buffer := bytes.Buffer{}
+	err := t.template.Execute(&buffer, value)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (t *Transformation) transformElement(value interface{}) interface{} {
+	if len(t.mapping) > 0 {
+		mapped := make(map[string]interface{}, len(t.mapping))
+		for _, mapping := range t.mapping {
+			field, err := mapping.selector.Lookup(value)
+			if err != nil {
+				continue
+			}
+			setPath(mapped, mapping.path, copyValue(field))
+		}
+		value = mapped
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	if len(t.include) > 0 {
+		included := make(map[string]interface{}, len(t.include))
+		for _, path := range t.include {
+			if field, ok := getPath(object, path); ok {
+				setPath(included, path, field)
+			}
+		}
+		object = included
+	}
+	for _, path := range t.exclude {
+		deletePath(object, path)
+	}
+	for _, rename := range t.rename {
+		if field, ok := getPath(object, rename.from); ok {
+			deletePath(object, rename.from)
+			setPath(object, rename.to, field)
+		}
+	}
+	for _, d := range t.defaults {
+		if field, ok := getPath(object, d.path); !ok || field == nil {
+			setPath(object, d.path, copyValue(d.value))
+		}
+	}
+	return object
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "$."), ".")
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getPath(object map[string]interface{}, path []string) (interface{}, bool) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		object = child
+	}
+	value, ok := object[path[len(path)-1]]
+	return value, ok
+}
+
+func setPath(object map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			object[key] = child
+		}
+		object = child
+	}
+	object[path[len(path)-1]] = value
+}
+
+func deletePath(object map[string]interface{}, path []string) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := object[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		object = child
+	}
+	delete(object, path[len(path)-1])
+}
+
+// copyValue deep copies objects and arrays, params are converted to objects
+func copyValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(value))
+		for key, field := range value {
+			object[key] = copyValue(field)
+		}
+		return object
+	case map[string]string:
+		object := make(map[string]interface{}, len(value))
+		for key, field := range value {
+			object[key] = field
+		}
+		return object
+	case []interface{}:
+		array := make([]interface{}, len(value))
+		for i, element := range value {
+			array[i] = copyValue(element)
+		}
+		return array
+	}
+	return value
+}
@@ -0,0 +1,203 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+	"github.com/project-flogo/core/data/schema"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	// LocationContent is the location of violations in the content
+	LocationContent = "content"
+	// LocationQueryParams is the location of violations in the query parameters
+	LocationQueryParams = "queryParams"
+	// LocationHeaders is the location of violations in the headers
+	LocationHeaders = "headers"
+	// SchemaReference is the prefix of a reference to a schema registered with the app
+	SchemaReference = "schema://"
+	// FileReference is the prefix of a reference to a schema file
+	FileReference = "file://"
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new request validator
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{}
+	act.content, err = LoadSchema(settings.ContentSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contentSchema: %v", err)
+	}
+	act.queryParams, err = LoadSchema(settings.QueryParamsSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid queryParamsSchema: %v", err)
+	}
+	act.headers, err = LoadSchema(settings.HeadersSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid headersSchema: %v", err)
+	}
+	return act, nil
+}
+
+// LoadSchema loads a JSON schema which is either inline, a reference to a schema registered
+// with the app (schema://id), or a path to a file
+func LoadSchema(value interface{}) (*gojsonschema.Schema, error) {
+	var loader gojsonschema.JSONLoader
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "":
+			return nil, nil
+		case strings.HasPrefix(value, "{"):
+			loader = gojsonschema.NewStringLoader(value)
+		case strings.HasPrefix(value, SchemaReference):
+			id := strings.TrimPrefix(value, SchemaReference)
+			registered := schema.Get(id)
+			if registered == nil {
+				return nil, fmt.Errorf("schema '%s' is not registered", id)
+			}
+			if registered.Type() == "" {
+				return nil, fmt.Errorf("schema '%s' is empty, schema support may not be enabled", id)
+			}
+			if registered.Type() != "json" {
+				return nil, fmt.Errorf("schema '%s' is not a JSON schema", id)
+			}
+			loader = gojsonschema.NewStringLoader(registered.Value())
+		default:
+			data, err := ioutil.ReadFile(strings.TrimPrefix(value, FileReference))
+			if err != nil {
+				return nil, err
+			}
+			loader = gojsonschema.NewBytesLoader(data)
+		}
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		loader = gojsonschema.NewBytesLoader(data)
+	}
+	return gojsonschema.NewSchema(loader)
+}
+
+// Activity is a request validator
+type Activity struct {
+	content     *gojsonschema.Schema
+	queryParams *gojsonschema.Schema
+	headers     *gojsonschema.Schema
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	violations := make([]interface{}, 0, 8)
+	validate := func(s *gojsonschema.Schema, location string, document interface{}) {
+		if s == nil {
+			return
+		}
+		result, err := s.Validate(gojsonschema.NewGoLoader(document))
+		if err != nil {
+			violations = append(violations, Violation(location, "", "invalid", err.Error()))
+			return
+		}
+		for _, resultError := range result.Errors() {
+			violations = append(violations, NewViolation(location, resultError))
+		}
+	}
+	validate(a.content, LocationContent, input.Content)
+	validate(a.queryParams, LocationQueryParams, toObject(input.QueryParams, false))
+	validate(a.headers, LocationHeaders, toObject(input.Headers, true))
+
+	output := Output{
+		Valid:      len(violations) == 0,
+		Violations: violations,
+	}
+	if !output.Valid {
+		first := violations[0].(map[string]interface{})
+		output.ValidationMessage = fmt.Sprintf("%s%s: %s", first["location"], first["pointer"], first["message"])
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// NewViolation converts a JSON schema error to a violation with a JSON pointer to the invalid value
+func NewViolation(location string, resultError gojsonschema.ResultError) map[string]interface{} {
+	// the context is split on NUL, which is not expected in property names, to escape each part
+	parts := strings.Split(resultError.Context().String("\x00"), "\x00")
+	if len(parts) > 0 && parts[0] == gojsonschema.STRING_CONTEXT_ROOT {
+		parts = parts[1:]
+	}
+	if resultError.Type() == "required" {
+		if property, ok := resultError.Details()["property"].(string); ok {
+			parts = append(parts, property)
+		}
+	}
+	pointer := ""
+	for _, part := range parts {
+		pointer += "/" + escape(part)
+	}
+	return Violation(location, pointer, resultError.Type(), resultError.Description())
+}
+
+// escape escapes a reference token of a JSON pointer as defined in RFC 6901
+func escape(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// Violation creates a violation
+func Violation(location, pointer, kind, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"location": location,
+		"pointer":  pointer,
+		"type":     kind,
+		"message":  message,
+	}
+}
+
+// toObject converts query parameters or headers to a JSON object, with canonical header names
+func toObject(values map[string]string, headers bool) map[string]interface{} {
+	object := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if headers {
+			key = http.CanonicalHeaderKey(key)
+		}
+		object[key] = value
+	}
+	return object
+}
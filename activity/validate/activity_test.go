@@ -0,0 +1,257 @@
+package validate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	"github.com/project-flogo/core/data/schema"
+	_ "github.com/project-flogo/core/data/schema/json"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+const petSchema = `{
+  "type": "object",
+  "required": ["name", "tags"],
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "age": {"type": "integer", "minimum": 0},
+    "tags": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["id"],
+        "properties": {"id": {"type": "integer"}}
+      }
+    }
+  }
+}`
+
+func validate(t *testing.T, act activity.Activity, values map[string]interface{}) map[string]interface{} {
+	ctx := newActivityContext(values)
+	_, err := act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func TestValidate(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"contentSchema": petSchema,
+		"queryParamsSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"limit": map[string]interface{}{"type": "string", "pattern": "^[0-9]+$"},
+			},
+		},
+		"headersSchema": `{"type": "object", "required": ["X-Request-Id"]}`,
+	}))
+	assert.Nil(t, err)
+
+	output := validate(t, act, map[string]interface{}{
+		"content": map[string]interface{}{
+			"name": "fido",
+			"tags": []interface{}{map[string]interface{}{"id": 1}},
+		},
+		"queryParams": map[string]string{"limit": "10"},
+		"headers":     map[string]string{"x-request-id": "1"},
+	})
+	assert.True(t, output["valid"].(bool))
+	assert.Len(t, output["violations"], 0)
+	assert.Equal(t, "", output["validationMessage"])
+
+	output = validate(t, act, map[string]interface{}{
+		"content": map[string]interface{}{
+			"name": "fido",
+			"age":  -1,
+			"tags": []interface{}{map[string]interface{}{"id": 1}, map[string]interface{}{"name": "dog"}},
+		},
+		"queryParams": map[string]string{"limit": "ten"},
+	})
+	assert.False(t, output["valid"].(bool))
+	violations := output["violations"].([]interface{})
+	assert.Len(t, violations, 4)
+	pointers := make(map[string]string)
+	for _, violation := range violations {
+		violation := violation.(map[string]interface{})
+		pointers[violation["location"].(string)+violation["pointer"].(string)] = violation["type"].(string)
+		assert.NotEmpty(t, violation["message"])
+	}
+	assert.Equal(t, map[string]string{
+		"content/age":          "number_gte",
+		"content/tags/1/id":    "required",
+		"queryParams/limit":    "pattern",
+		"headers/X-Request-Id": "required",
+	}, pointers)
+	assert.Contains(t, output["validationMessage"], "content/")
+
+	output = validate(t, act, map[string]interface{}{
+		"content": "not an object",
+		"headers": map[string]string{"X-Request-Id": "1"},
+	})
+	assert.False(t, output["valid"].(bool))
+	violation := output["violations"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "", violation["pointer"])
+	assert.Equal(t, "invalid_type", violation["type"])
+}
+
+func TestViolationPointer(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"contentSchema": `{
+  "type": "object",
+  "required": ["a/b~c"],
+  "properties": {
+    "x/y": {"type": "string"},
+    "~1": {"type": "string"}
+  }
+}`,
+	}))
+	assert.Nil(t, err)
+
+	output := validate(t, act, map[string]interface{}{
+		"content": map[string]interface{}{"x/y": 1, "~1": 2},
+	})
+	assert.False(t, output["valid"].(bool))
+	pointers := make([]string, 0, 3)
+	for _, violation := range output["violations"].([]interface{}) {
+		pointers = append(pointers, violation.(map[string]interface{})["pointer"].(string))
+	}
+	assert.ElementsMatch(t, []string{"/a~1b~0c", "/x~1y", "/~01"}, pointers, "pointers should be escaped as defined in RFC 6901")
+}
+
+func TestValidateSchemaSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "pet.json")
+	err = ioutil.WriteFile(file, []byte(petSchema), 0644)
+	assert.Nil(t, err)
+
+	schema.Enable()
+	_, err = schema.Register("pet", &schema.Def{Type: "json", Value: petSchema})
+	assert.Nil(t, err)
+
+	content := map[string]interface{}{"name": ""}
+	for _, source := range []string{file, "file://" + file, "schema://pet"} {
+		act, err := New(newInitContext(map[string]interface{}{
+			"contentSchema": source,
+		}))
+		assert.Nil(t, err, source)
+		output := validate(t, act, map[string]interface{}{
+			"content": content,
+		})
+		assert.False(t, output["valid"].(bool), source)
+		assert.Len(t, output["violations"], 2, source)
+	}
+
+	for _, source := range []string{filepath.Join(dir, "missing.json"), "schema://missing", `{"type": 1}`} {
+		_, err := New(newInitContext(map[string]interface{}{
+			"contentSchema": source,
+		}))
+		assert.NotNil(t, err, source)
+	}
+}
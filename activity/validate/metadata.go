@@ -0,0 +1,80 @@
+package validate
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the request validator
+type Settings struct {
+	ContentSchema     interface{} `md:"contentSchema"`
+	QueryParamsSchema interface{} `md:"queryParamsSchema"`
+	HeadersSchema     interface{} `md:"headersSchema"`
+}
+
+// Input is the input for the request validator
+type Input struct {
+	Content     interface{}       `md:"content"`
+	QueryParams map[string]string `md:"queryParams"`
+	Headers     map[string]string `md:"headers"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	r.Content = values["content"]
+	queryParams, err := coerce.ToParams(values["queryParams"])
+	if err != nil {
+		return err
+	}
+	r.QueryParams = queryParams
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"content":     r.Content,
+		"queryParams": r.QueryParams,
+		"headers":     r.Headers,
+	}
+}
+
+// Output is the output of the request validator
+type Output struct {
+	Valid             bool          `md:"valid"`
+	Violations        []interface{} `md:"violations"`
+	ValidationMessage string        `md:"validationMessage"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	valid, err := coerce.ToBool(values["valid"])
+	if err != nil {
+		return err
+	}
+	o.Valid = valid
+	violations, err := coerce.ToArray(values["violations"])
+	if err != nil {
+		return err
+	}
+	o.Violations = violations
+	validationMessage, err := coerce.ToString(values["validationMessage"])
+	if err != nil {
+		return err
+	}
+	o.ValidationMessage = validationMessage
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"valid":             o.Valid,
+		"violations":        o.Violations,
+		"validationMessage": o.ValidationMessage,
+	}
+}
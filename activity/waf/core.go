@@ -0,0 +1,96 @@
+package waf
+
+var (
+	// CoreRules are the built-in rules for cross site scripting, path traversal and command injection
+	CoreRules []*Rule
+
+	coreRules = []interface{}{
+		map[string]interface{}{
+			"id":         "941100",
+			"message":    "XSS: script tag",
+			"targets":    []interface{}{TargetPath, TargetQuery, TargetBody, "headers:User-Agent", "headers:Referer"},
+			"value":      `(?i)<\s*script[\s/>]`,
+			"transforms": []interface{}{"urlDecode", "htmlEntityDecode", "removeNulls"},
+		},
+		map[string]interface{}{
+			"id":         "941110",
+			"message":    "XSS: event handler attribute",
+			"targets":    []interface{}{TargetPath, TargetQuery, TargetBody, "headers:User-Agent", "headers:Referer"},
+			"value":      `(?i)[\s"'/]on(?:error|load|click|dblclick|mouse\w+|focus\w*|blur|key\w+|submit|change|input|toggle|animation\w+|pointer\w+)\s*=`,
+			"transforms": []interface{}{"urlDecode", "htmlEntityDecode", "removeNulls"},
+		},
+		map[string]interface{}{
+			"id":         "941120",
+			"message":    "XSS: script URI",
+			"targets":    []interface{}{TargetPath, TargetQuery, TargetBody, "headers:Referer"},
+			"value":      `(?i)(?:java|vb)script\s*:`,
+			"transforms": []interface{}{"urlDecode", "htmlEntityDecode", "removeNulls", "compressWhitespace"},
+		},
+		map[string]interface{}{
+			"id":         "941130",
+			"message":    "XSS: embedding element",
+			"targets":    []interface{}{TargetPath, TargetQuery, TargetBody},
+			"value":      `(?i)<\s*(?:iframe|frame|object|embed|applet|base|meta)\b`,
+			"transforms": []interface{}{"urlDecode", "htmlEntityDecode", "removeNulls"},
+		},
+		map[string]interface{}{
+			"id":      "930100",
+			"message": "Path traversal: encoded dot dot slash",
+			"targets": []interface{}{TargetPath, TargetQuery, TargetBody},
+			"value":   `(?i)(?:%2e|%c0%ae|%252e|\.){2}(?:%2f|%5c|%c0%af|%252f|%255c)|(?:%2e|%c0%ae|%252e){2}(?:/|\\)`,
+		},
+		map[string]interface{}{
+			"id":         "930110",
+			"message":    "Path traversal: dot dot slash",
+			"targets":    []interface{}{TargetPath, TargetQuery, TargetBody},
+			"value":      `(?:^|[\\/])\.\.(?:[\\/]|$)`,
+			"transforms": []interface{}{"urlDecode", "urlDecode", "removeNulls"},
+		},
+		map[string]interface{}{
+			"id":         "930120",
+			"message":    "Path traversal: operating system file access",
+			"targets":    []interface{}{TargetPath, TargetQuery, TargetBody},
+			"value":      `(?i)(?:/etc/(?:passwd|shadow|group|hosts)|/proc/self/|boot\.ini|win\.ini|system32[\\/])`,
+			"transforms": []interface{}{"urlDecode", "removeNulls"},
+		},
+		map[string]interface{}{
+			"id":         "932100",
+			"message":    "Command injection: chained Unix command",
+			"targets":    []interface{}{TargetPath, TargetQuery},
+			"value":      `(?:[;|\x60\n]|&&|\$\()\s*(?:cat|ls|id|whoami|uname|wget|curl|nc|ncat|bash|sh|zsh|rm|chmod|chown|python\d?|perl|ruby|php|ping|nslookup|sleep)(?:\s|$|[;|&)\x60])`,
+			"transforms": []interface{}{"urlDecode", "removeNulls"},
+		},
+		// free text in bodies often looks like chained commands, so matches only add to the score
+		map[string]interface{}{
+			"id":         "932105",
+			"message":    "Command injection: chained Unix command in the body",
+			"targets":    []interface{}{TargetBody},
+			"severity":   "notice",
+			"value":      `(?:[;|\x60\n]|&&|\$\()\s*(?:cat|ls|id|whoami|uname|wget|curl|nc|ncat|bash|sh|zsh|rm|chmod|chown|python\d?|perl|ruby|php|ping|nslookup|sleep)(?:\s|$|[;|&)\x60])`,
+			"transforms": []interface{}{"urlDecode", "removeNulls"},
+		},
+		map[string]interface{}{
+			"id":         "932110",
+			"message":    "Command injection: Windows command",
+			"targets":    []interface{}{TargetPath, TargetQuery, TargetBody},
+			"value":      `(?i)(?:[;&|]|^)\s*(?:cmd(?:\.exe)?\s+/[ck]|powershell(?:\.exe)?\s+-|net\s+user\b)`,
+			"transforms": []interface{}{"urlDecode", "removeNulls", "compressWhitespace"},
+		},
+		map[string]interface{}{
+			"id":         "932120",
+			"message":    "Command injection: shell invocation",
+			"targets":    []interface{}{TargetPath, TargetQuery, TargetBody},
+			"severity":   "error",
+			"value":      `(?:/bin/(?:ba|z|da)?sh|/usr/bin/(?:env|perl|python\d?))\b`,
+			"transforms": []interface{}{"urlDecode", "removeNulls"},
+		},
+	}
+)
+
+func init() {
+	var err error
+	CoreRules, err = ParseRules(coreRules)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,103 @@
+package waf
+
+import (
+	"github.com/project-flogo/core/data/coerce"
+)
+
+// Settings are the settings for the WAF
+type Settings struct {
+	Rules         []interface{} `md:"rules"`
+	RulesFile     string        `md:"rulesFile"`
+	CoreRules     bool          `md:"coreRules"`
+	DisabledRules []interface{} `md:"disabledRules"`
+	Threshold     int           `md:"threshold"`
+}
+
+// Input is the input for the WAF
+type Input struct {
+	Path        string            `md:"path"`
+	PathParams  map[string]string `md:"pathParams"`
+	QueryParams map[string]string `md:"queryParams"`
+	Headers     map[string]string `md:"headers"`
+	Content     interface{}       `md:"content"`
+}
+
+// FromMap converts the input from a map to a struct
+func (r *Input) FromMap(values map[string]interface{}) error {
+	path, err := coerce.ToString(values["path"])
+	if err != nil {
+		return err
+	}
+	r.Path = path
+	pathParams, err := coerce.ToParams(values["pathParams"])
+	if err != nil {
+		return err
+	}
+	r.PathParams = pathParams
+	queryParams, err := coerce.ToParams(values["queryParams"])
+	if err != nil {
+		return err
+	}
+	r.QueryParams = queryParams
+	headers, err := coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Headers = headers
+	r.Content = values["content"]
+	return nil
+}
+
+// ToMap converts the input to a map from a struct
+func (r *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"path":        r.Path,
+		"pathParams":  r.PathParams,
+		"queryParams": r.QueryParams,
+		"headers":     r.Headers,
+		"content":     r.Content,
+	}
+}
+
+// Output is the output of the WAF
+type Output struct {
+	Blocked bool          `md:"blocked"`
+	Score   int           `md:"score"`
+	Matches []interface{} `md:"matches"`
+	Message string        `md:"message"`
+}
+
+// FromMap converts the output from a map to a struct
+func (o *Output) FromMap(values map[string]interface{}) error {
+	blocked, err := coerce.ToBool(values["blocked"])
+	if err != nil {
+		return err
+	}
+	o.Blocked = blocked
+	score, err := coerce.ToInt(values["score"])
+	if err != nil {
+		return err
+	}
+	o.Score = score
+	matches, err := coerce.ToArray(values["matches"])
+	if err != nil {
+		return err
+	}
+	o.Matches = matches
+	message, err := coerce.ToString(values["message"])
+	if err != nil {
+		return err
+	}
+	o.Message = message
+	return nil
+}
+
+// ToMap converts the output to a map from a struct
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"blocked": o.Blocked,
+		"score":   o.Score,
+		"matches": o.Matches,
+		"message": o.Message,
+	}
+}
@@ -0,0 +1,294 @@
+package waf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/project-flogo/core/data/coerce"
+	"github.com/project-flogo/microgateway/internal/watch"
+)
+
+const (
+	// TargetPath is the path of the request and its path parameters
+	TargetPath = "path"
+	// TargetQuery is the names and values of the query parameters
+	TargetQuery = "query"
+	// TargetHeaders is the values of the headers
+	TargetHeaders = "headers"
+	// TargetBody is the strings and keys of the content
+	TargetBody = "body"
+	// OperatorRegex matches a regular expression
+	OperatorRegex = "regex"
+	// OperatorContains matches a value which contains a string
+	OperatorContains = "contains"
+	// OperatorEquals matches a value equal to a string
+	OperatorEquals = "equals"
+	// OperatorBeginsWith matches a value which begins with a string
+	OperatorBeginsWith = "beginsWith"
+	// OperatorEndsWith matches a value which ends with a string
+	OperatorEndsWith = "endsWith"
+	// OperatorPhrases matches a value which contains one of a list of phrases
+	OperatorPhrases = "phrases"
+	// OperatorGreaterThan matches a number greater than a number
+	OperatorGreaterThan = "gt"
+	// OperatorLessThan matches a number less than a number
+	OperatorLessThan = "lt"
+	// DefaultSeverity is the default severity of a rule
+	DefaultSeverity = "critical"
+)
+
+var (
+	// Severities are the anomaly scores of the severities
+	Severities = map[string]int{
+		"critical": 5,
+		"error":    4,
+		"warning":  3,
+		"notice":   2,
+	}
+	// Transforms are the transformations applied to values before they are matched
+	Transforms = map[string]func(string) string{
+		"lowercase":          strings.ToLower,
+		"urlDecode":          urlDecode,
+		"htmlEntityDecode":   html.UnescapeString,
+		"compressWhitespace": compressWhitespace,
+		"removeNulls":        removeNulls,
+	}
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// Rule is a WAF rule which matches the values of targets in a request with an operator
+type Rule struct {
+	ID         string
+	Message    string
+	Severity   string
+	Score      int
+	Targets    []string
+	Operator   string
+	Value      string
+	Values     []string
+	Transforms []string
+	Negate     bool
+	regex      *regexp.Regexp
+	number     float64
+}
+
+// ParseRules parses rules from the settings or a rule file
+func ParseRules(values []interface{}) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(values))
+	ids := make(map[string]bool, len(values))
+	for i, value := range values {
+		settings, err := coerce.ToObject(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %v", i, err)
+		}
+		rule := &Rule{}
+		for key, setting := range settings {
+			switch key {
+			case "id":
+				rule.ID, err = coerce.ToString(setting)
+			case "message":
+				rule.Message, err = coerce.ToString(setting)
+			case "severity":
+				rule.Severity, err = coerce.ToString(setting)
+			case "score":
+				rule.Score, err = coerce.ToInt(setting)
+			case "targets":
+				rule.Targets, err = toStrings(setting)
+			case "operator":
+				rule.Operator, err = coerce.ToString(setting)
+			case "value":
+				rule.Value, err = coerce.ToString(setting)
+			case "values":
+				rule.Values, err = toStrings(setting)
+			case "transforms":
+				rule.Transforms, err = toStrings(setting)
+			case "negate":
+				rule.Negate, err = coerce.ToBool(setting)
+			default:
+				err = fmt.Errorf("unknown field '%s'", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %d: %v", i, err)
+			}
+		}
+		if rule.ID == "" {
+			return nil, fmt.Errorf("invalid rule %d: an id is required", i)
+		}
+		if ids[rule.ID] {
+			return nil, fmt.Errorf("duplicate rule: %s", rule.ID)
+		}
+		ids[rule.ID] = true
+		err = rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %v", rule.ID, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// compile checks the rule and compiles its operand
+func (r *Rule) compile() error {
+	if r.Severity == "" {
+		r.Severity = DefaultSeverity
+	}
+	score, ok := Severities[r.Severity]
+	if !ok {
+		return fmt.Errorf("unknown severity '%s'", r.Severity)
+	}
+	if r.Score == 0 {
+		r.Score = score
+	}
+	if len(r.Targets) == 0 {
+		return errors.New("at least one target is required")
+	}
+	for _, target := range r.Targets {
+		switch kind, _ := splitTarget(target); kind {
+		case TargetPath, TargetQuery, TargetHeaders, TargetBody:
+		default:
+			return fmt.Errorf("unknown target '%s'", target)
+		}
+	}
+	for _, transform := range r.Transforms {
+		if Transforms[transform] == nil {
+			return fmt.Errorf("unknown transform '%s'", transform)
+		}
+	}
+
+	var err error
+	switch r.Operator {
+	case "", OperatorRegex:
+		r.Operator = OperatorRegex
+		r.regex, err = regexp.Compile(r.Value)
+	case OperatorContains, OperatorEquals, OperatorBeginsWith, OperatorEndsWith:
+		if r.Value == "" {
+			err = errors.New("a value is required")
+		}
+	case OperatorPhrases:
+		if len(r.Values) == 0 {
+			err = errors.New("values are required")
+		}
+	case OperatorGreaterThan, OperatorLessThan:
+		r.number, err = strconv.ParseFloat(r.Value, 64)
+	default:
+		err = fmt.Errorf("unknown operator '%s'", r.Operator)
+	}
+	return err
+}
+
+// Match matches a value with the rule, after applying the transforms
+func (r *Rule) Match(value string) bool {
+	for _, transform := range r.Transforms {
+		value = Transforms[transform](value)
+	}
+	var matched bool
+	switch r.Operator {
+	case OperatorRegex:
+		matched = r.regex.MatchString(value)
+	case OperatorContains:
+		matched = strings.Contains(value, r.Value)
+	case OperatorEquals:
+		matched = value == r.Value
+	case OperatorBeginsWith:
+		matched = strings.HasPrefix(value, r.Value)
+	case OperatorEndsWith:
+		matched = strings.HasSuffix(value, r.Value)
+	case OperatorPhrases:
+		for _, phrase := range r.Values {
+			if strings.Contains(value, phrase) {
+				matched = true
+				break
+			}
+		}
+	case OperatorGreaterThan, OperatorLessThan:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return false
+		}
+		matched = (r.Operator == OperatorGreaterThan && number > r.number) ||
+			(r.Operator == OperatorLessThan && number < r.number)
+	}
+	return matched != r.Negate
+}
+
+// splitTarget splits a target into its kind and an optional name, such as headers:User-Agent
+func splitTarget(target string) (string, string) {
+	if i := strings.Index(target, ":"); i >= 0 {
+		return target[:i], target[i+1:]
+	}
+	return target, ""
+}
+
+func urlDecode(value string) string {
+	decoded, err := url.QueryUnescape(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+func compressWhitespace(value string) string {
+	return whitespace.ReplaceAllString(value, " ")
+}
+
+func removeNulls(value string) string {
+	return strings.Replace(value, "\x00", "", -1)
+}
+
+func toStrings(value interface{}) ([]string, error) {
+	values, err := coerce.ToArray(value)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		s, err := coerce.ToString(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// FileRules are rules loaded from a JSON rule file, which is reloaded when it changes
+type FileRules struct {
+	*watch.File
+}
+
+// NewFileRules creates new file rules and loads the file
+func NewFileRules(path string) (*FileRules, error) {
+	file, err := watch.NewFile(path, "rule file", parseRuleFile)
+	if err != nil {
+		return nil, err
+	}
+	return &FileRules{File: file}, nil
+}
+
+// parseRuleFile parses a rule file, which is either an array of rules or an object with rules
+func parseRuleFile(data []byte) (interface{}, error) {
+	var document interface{}
+	err := json.Unmarshal(data, &document)
+	if err != nil {
+		return nil, err
+	}
+	if object, ok := document.(map[string]interface{}); ok {
+		document = object["rules"]
+	}
+	values, ok := document.([]interface{})
+	if !ok {
+		return nil, errors.New("no rules")
+	}
+	return ParseRules(values)
+}
+
+// Rules returns the rules, reloading the file when it has changed
+func (f *FileRules) Rules() []*Rule {
+	return f.Value().([]*Rule)
+}
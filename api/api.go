@@ -108,6 +108,16 @@ func (s *Step) SetHalt(condition string) {
 	s.HaltCondition = condition
 }
 
+// SetMirror mirrors a percentage of the executions of the step to service,
+// the outputs of both services are compared and the differences logged if compare is true
+func (s *Step) SetMirror(service *Service, percentage float64, compare bool) {
+	s.Mirror = &Mirror{
+		Service:    service.Name,
+		Percentage: percentage,
+		Compare:    compare,
+	}
+}
+
 // SetIf sets the condition for the response
 func (r *Response) SetIf(condition string) {
 	r.Condition = condition
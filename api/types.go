@@ -16,6 +16,15 @@ type Step struct {
 	Service       string                 `json:"service" jsonschema:"required"`
 	Input         map[string]interface{} `json:"input,omitempty" jsonschema:"additionalProperties"`
 	HaltCondition string                 `json:"halt,omitempty"`
+	Mirror        *Mirror                `json:"mirror,omitempty"`
+}
+
+// Mirror asynchronously sends a sample of the inputs of a step to a second service.
+// The second service never changes the response of the microgateway
+type Mirror struct {
+	Service    string  `json:"service" jsonschema:"required"`
+	Percentage float64 `json:"percentage,omitempty"`
+	Compare    bool    `json:"compare,omitempty"`
 }
 
 // Response defines response handling rules.
@@ -31,6 +40,12 @@ type Output struct {
 	Data interface{} `json:"data" jsonschema:"additionalProperties"`
 }
 
+// Deferrer is implemented by the activity host of a microgateway execution.
+// Deferred functions are called after the steps have executed, even when a step fails.
+type Deferrer interface {
+	Defer(f func())
+}
+
 // ServiceFunc is a function to be called for a service
 type ServiceFunc func(ctx activity.Context) (done bool, err error)
 
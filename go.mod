@@ -1,11 +1,13 @@
 module github.com/project-flogo/microgateway
 
 require (
+	github.com/alicebob/miniredis/v2 v2.14.1
 	github.com/awalterschulze/gographviz v0.0.0-20170410065617-c84395e536e1 // indirect
 	github.com/chewxy/hm v1.0.0 // indirect
 	github.com/chewxy/math32 v1.0.0 // indirect
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/gogo/protobuf v1.1.1 // indirect
 	github.com/golang/protobuf v1.2.0 // indirect
 	github.com/gonum/blas v0.0.0-20180125090452-e7c5890b24cf // indirect
@@ -13,6 +15,7 @@ require (
 	github.com/graph-gophers/graphql-go v0.0.0-20191115155744-f33e81362277
 	github.com/graphql-go/graphql v0.7.8
 	github.com/leesper/go_rng v0.0.0-20171009123644-5344a9259b21 // indirect
+	github.com/oliveagle/jsonpath v0.0.0-20180606110733-2e52cf6e6852
 	github.com/pkg/errors v0.8.0 // indirect
 	github.com/project-flogo/contrib v0.9.0-alpha.4.0.20190509204259-4246269fb68e
 	github.com/project-flogo/contrib/activity/channel v0.9.0-rc.1.0.20190509204259-4246269fb68e
@@ -3,7 +3,10 @@ package core
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -14,6 +17,13 @@ import (
 	"github.com/project-flogo/core/support/trace"
 )
 
+// MaxMirrors is the maximum number of mirror services running at the same time,
+// further mirrors are dropped until one of them completes
+const MaxMirrors = 64
+
+// mirrors holds a token for each running mirror service
+var mirrors = make(chan struct{}, MaxMirrors)
+
 type microgatewayHost struct {
 	id         string
 	name       string
@@ -21,6 +31,7 @@ type microgatewayHost struct {
 	iometadata *metadata.IOMetadata
 	err        error
 	halt       bool
+	deferred   []func()
 }
 
 func (m *microgatewayHost) ID() string {
@@ -51,6 +62,18 @@ func (m *microgatewayHost) Scope() data.Scope {
 	return m.scope
 }
 
+// Defer adds a function to be called after the steps have executed
+func (m *microgatewayHost) Defer(f func()) {
+	m.deferred = append(m.deferred, f)
+}
+
+func (m *microgatewayHost) runDeferred() {
+	for i := len(m.deferred) - 1; i >= 0; i-- {
+		m.deferred[i]()
+	}
+	m.deferred = nil
+}
+
 // Execute executes the microgateway
 func Execute(id string, payload interface{}, definition *Microgateway, iometadata *metadata.IOMetadata, log logger.Logger) (code int, output interface{}, err error) {
 
@@ -79,6 +102,7 @@ func Execute(id string, payload interface{}, definition *Microgateway, iometadat
 		if definition.Async {
 			log.Info("executing route asynchronously")
 			go func() {
+				defer host.runDeferred()
 				done, err := executeSteps(definition, &host, log)
 				if err != nil {
 					if done {
@@ -89,6 +113,7 @@ func Execute(id string, payload interface{}, definition *Microgateway, iometadat
 				}
 			}()
 		} else {
+			defer host.runDeferred()
 			var done bool
 			done, err = executeSteps(definition, &host, log)
 			if err != nil {
@@ -170,6 +195,9 @@ func executeSteps(definition *Microgateway, host *microgatewayHost, log logger.L
 		ctxt := newServiceContext(step.Service, host, log)
 		if truthiness {
 			done, err = invokeService(step.Service, step.HaltCondition, host, ctxt, step.Input, log)
+			if step.Mirror != nil {
+				mirrorService(&step, host, ctxt, log)
+			}
 			if err != nil {
 				return done, err
 			}
@@ -307,6 +335,90 @@ func invokeService(serviceDef *Service, haltCondition *Expr, host *microgatewayH
 	return done, err
 }
 
+// mirrorService invokes the mirror service of a step in a goroutine with the inputs of the step.
+// The mirror service has its own host and scope, so it can't change the execution of the route.
+// The mirror is dropped when MaxMirrors mirror services are already running
+func mirrorService(step *Step, host *microgatewayHost, primary *serviceContext, log logger.Logger) {
+	mirror := step.Mirror
+	if mirror.Percentage < 100 && rand.Float64()*100 >= mirror.Percentage {
+		return
+	}
+
+	values := make(map[string]interface{}, 4)
+	for _, name := range []string{"payload", "async", "env", "conf"} {
+		values[name], _ = host.scope.GetValue(name)
+	}
+	mirrorHost := &microgatewayHost{
+		id:         host.id,
+		name:       host.name,
+		scope:      data.NewSimpleScope(values, nil),
+		iometadata: host.iometadata,
+	}
+	ctxt := newServiceContext(mirror.Service, mirrorHost, log)
+	err := TranslateMappingsToTree(host.Scope(), step.Input, ctxt.Inputs, log)
+	if err != nil {
+		log.Info("error translating inputs of mirror service: ", err)
+		return
+	}
+	primaryErr, _ := primary.values["error"].(error)
+	primaryOutputs, primaryName := primary.Outputs, step.Service.Name
+
+	select {
+	case mirrors <- struct{}{}:
+	default:
+		log.Warnf("dropping mirror of service %s to %s, %d mirrors are running", primaryName, mirror.Service.Name, MaxMirrors)
+		return
+	}
+	log.Info("mirroring service ", primaryName, " to ", mirror.Service.Name)
+	go func() {
+		defer func() {
+			<-mirrors
+		}()
+		defer mirrorHost.runDeferred()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("mirror service panicked: ", r)
+			}
+		}()
+		_, err := mirror.Service.Activity.Eval(ctxt)
+		if err == nil {
+			err = mirrorHost.err
+		}
+		if err != nil {
+			log.Info("error executing mirror service: ", err)
+		}
+		if !mirror.Compare {
+			return
+		}
+		differences := compareOutputs(primaryOutputs, ctxt.Outputs)
+		if (primaryErr == nil) != (err == nil) {
+			differences = append(differences, "error")
+		}
+		if len(differences) > 0 {
+			log.Warnf("mirror service %s differs from %s: %s", mirror.Service.Name, primaryName, strings.Join(differences, ", "))
+		} else {
+			log.Debugf("mirror service %s matches %s", mirror.Service.Name, primaryName)
+		}
+	}()
+}
+
+// compareOutputs returns the sorted names of the outputs which differ
+func compareOutputs(a, b map[string]interface{}) []string {
+	var differences []string
+	for name, value := range a {
+		if other, ok := b[name]; !ok || !reflect.DeepEqual(value, other) {
+			differences = append(differences, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			differences = append(differences, name)
+		}
+	}
+	sort.Strings(differences)
+	return differences
+}
+
 // TranslateMappings translates dot notation mappings
 func TranslateMappings(scope data.Scope, mappings []*Expr, log logger.Logger) (tree map[string]interface{}, err error) {
 	length := len(mappings)
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/project-flogo/core/activity"
+	"github.com/project-flogo/core/data"
+	logger "github.com/project-flogo/core/support/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingActivity struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingActivity) Metadata() *activity.Metadata {
+	return nil
+}
+
+func (b *blockingActivity) Eval(ctx activity.Context) (done bool, err error) {
+	b.started <- struct{}{}
+	<-b.release
+	return true, nil
+}
+
+func TestMirrorServiceLimit(t *testing.T) {
+	log := logger.ChildLogger(logger.RootLogger(), "test")
+	shadow := &blockingActivity{
+		started: make(chan struct{}, 2*MaxMirrors),
+		release: make(chan struct{}),
+	}
+	step := Step{
+		Service: &Service{Name: "primary"},
+		Mirror: &Mirror{
+			Service:    &Service{Name: "shadow", Activity: shadow},
+			Percentage: 100,
+		},
+	}
+	host := &microgatewayHost{
+		scope: data.NewSimpleScope(map[string]interface{}{}, nil),
+	}
+	primary := newServiceContext(step.Service, host, log)
+
+	for i := 0; i < MaxMirrors+8; i++ {
+		mirrorService(&step, host, primary, log)
+	}
+	for i := 0; i < MaxMirrors; i++ {
+		select {
+		case <-shadow.started:
+		case <-time.After(time.Second):
+			t.Fatal("service was not mirrored")
+		}
+	}
+	select {
+	case <-shadow.started:
+		t.Fatal("mirrors should be dropped when the limit is reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, MaxMirrors, len(mirrors))
+
+	close(shadow.release)
+	for deadline := time.Now().Add(time.Second); len(mirrors) > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, len(mirrors))
+	mirrorService(&step, host, primary, log)
+	select {
+	case <-shadow.started:
+	case <-time.After(time.Second):
+		t.Fatal("service should be mirrored once the running mirrors have completed")
+	}
+}
@@ -19,6 +19,14 @@ type Step struct {
 	Service       *Service
 	Input         []*Expr
 	HaltCondition *Expr
+	Mirror        *Mirror
+}
+
+// Mirror asynchronously invokes a second service with the inputs of a step
+type Mirror struct {
+	Service    *Service
+	Percentage float64
+	Compare    bool
 }
 
 // Setting is a service setting
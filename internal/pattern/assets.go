@@ -67,7 +67,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _defaulthttppatternJson = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xe4\x58\x5f\x6f\xdb\x36\x10\x7f\xcf\xa7\xb8\x0a\x41\x53\x03\xa9\x92\xfd\x79\x32\xe0\x87\x38\x0b\xd6\x75\x0b\x56\xb4\xee\xfa\x30\xec\x81\x96\xce\x16\x63\x8a\xd4\xc8\xa3\x33\xaf\xc9\x77\x1f\x44\x49\x0e\xf5\xc7\xb2\x1d\xc3\x01\x86\xbe\x24\x11\xef\x78\xfc\xdd\xef\x8e\x77\xbc\x7c\x3d\x01\x08\x24\x4b\x31\x18\x42\xf0\x13\xce\x98\x15\xf4\x8e\x28\xfb\xc0\x88\x50\xcb\xe0\x3c\x97\x1b\xc2\xcc\x04\x43\xf8\xf3\x04\x00\xe0\xab\xfb\x09\x10\xf0\x59\xbe\xe9\x34\x8c\x94\x9c\x85\xd6\xe0\x47\x46\xf8\x1b\x4f\x39\xa1\x86\xd1\x08\x48\x5b\x74\xfb\x9d\xb2\x41\xbd\xe4\x91\x3b\xc6\xd3\x7b\x92\x73\x99\x59\x0a\x86\x6b\xeb\x00\x01\xa9\x05\xca\x7c\xc3\x5c\xa8\x29\x13\x41\x29\x79\x74\xbf\x1f\xcf\xfb\xc1\xbc\xff\x32\xe9\x05\xf1\xfe\xcb\xe4\x0f\x26\x78\xcc\x48\xed\x88\x62\x74\x1a\x66\x6c\x25\x14\x8b\xc3\x04\x59\x8c\xda\x84\x57\x96\x12\xa5\xf9\xbf\x8c\xb8\x92\x6b\x2b\xf9\x41\x7c\x2e\xb9\x9c\xdf\x22\x25\x2a\x2e\x37\x3b\x64\x77\xf7\xf4\xa9\x26\xf3\x36\x2d\x70\xd5\x50\xfd\x15\x57\xbe\x02\xb3\x4d\x5b\x57\xb6\x66\x81\x1b\xd3\x50\xf8\xc5\x98\x1a\x2e\x3b\x6d\xa2\xb1\xd3\xfd\x88\xbd\xe6\x3a\xb2\x9c\xc6\x1a\xd9\x62\x4b\xa0\xeb\xaa\x41\x8f\xf9\x37\xad\xc0\xcd\x98\x30\x08\x0f\x0f\x70\x1a\xfa\xa1\x0a\x95\xa5\xcc\x92\x09\x97\xf9\x4a\x75\xfa\x00\x5e\xbf\x86\xd2\x44\xea\x78\xcd\x25\x67\x3f\xdf\x4c\xce\x02\xe8\x42\x96\xa7\xf8\x98\x45\x0b\x94\xf1\xd5\x13\xf4\x84\x09\x2a\xd1\xf8\x0a\x21\x6a\xad\x34\xbc\x1a\x81\xe4\xc2\x1d\xf5\xca\xad\x84\xdc\x48\x24\xf7\x67\xe7\x8e\xc1\xb1\x3d\x7e\xd3\x76\xf9\xc3\xe7\xc9\x59\x61\xa3\x25\xf9\xfd\xd3\x46\xd1\xd5\xe4\xfa\xdd\xd9\xa0\x33\x86\x9e\x5b\xe3\xde\x7b\x12\x29\x49\x28\xc9\x4f\xaf\x6a\xa9\xca\xaf\x2d\x44\x8f\xf7\x26\x7a\xbc\x03\xd1\xdb\x32\xd7\x11\xd9\x17\xf1\xcd\xb9\x35\x78\x78\x80\x3e\x17\x8e\x10\xa3\x41\x77\x94\x1a\x37\xad\x2f\x4e\x2a\x43\x5d\xd4\xab\x61\x1e\x34\x2b\xa9\xba\x9a\x87\x55\x80\x1e\x1e\x47\xcf\xe6\x71\xf4\x3f\xe1\x51\xa3\x41\x6a\xb0\x78\x02\xf0\x97\x6b\x9f\x1a\x4d\xa6\xa4\xc1\x9e\x16\xea\xb5\xc4\xf5\x7d\x17\xf9\xf7\x47\x64\x51\x82\x71\xbb\xcc\x3a\x7e\x82\xa1\x5b\x5d\x2f\x16\x5b\x9b\xf7\x32\xce\x5d\xfb\xf1\xf2\x07\xaf\x0f\xc4\x8c\x58\x4d\xcd\x75\x79\x46\xd6\x54\x0d\x1a\x1c\x1c\xb8\xf9\x27\x42\x8c\x31\x86\xb7\x30\x49\x10\x4a\xaa\x60\xa5\x2c\x24\x6c\x89\xa0\xf1\x6f\x8b\x86\x30\x06\x6e\x40\x2d\x51\x03\x25\x08\x4c\x08\x75\x8f\x31\x38\x17\xc2\x60\x7d\xca\xe3\x73\x5b\x78\x91\x3b\xfd\x65\xd1\xd5\xcf\x43\x08\xfa\x6e\x0b\x41\xce\xe2\x2d\x1a\xc3\xe6\x58\x96\xb9\x4e\x44\x35\xbd\x73\xdf\xc2\xb2\xd0\xe5\x4a\xee\x60\xa6\xad\xfc\x4c\x1e\x37\xdf\xd7\xd3\xb0\x2e\x5b\x9f\x4d\x9a\x67\xd9\xcb\xa4\x5d\x65\x31\x88\x0a\x28\x30\x2d\x71\x96\x18\xf6\xf3\xfa\x88\x0f\x89\x16\x07\xce\xf2\x2e\x24\x7c\x7f\x79\xd9\x26\x21\x8f\x7b\xad\x56\x56\x80\x9c\xfc\x85\x5c\x3d\xda\x0b\xe2\x28\x1c\x8d\xf7\xe0\x68\xff\x44\xed\x3a\xff\xc6\x59\xd9\x50\xd7\xcb\x62\xd8\x51\xd6\xab\x81\xaa\x73\xd2\x89\xd1\x44\x9a\x67\x55\xeb\x70\xc5\x56\x34\x95\x34\xba\x18\xcf\x39\x25\x76\x1a\x46\x2a\xbd\xc8\xb4\xba\xc3\x88\xde\xce\x84\x9a\xab\x8b\x94\x47\x5a\xcd\x19\xe1\x3d\x5b\x5d\xb0\x88\xf8\x92\xd3\xea\x42\x33\xc2\x96\x29\x83\x44\x5c\xce\x4d\xdd\x6d\xa7\xe6\x3f\xd6\x74\x85\xb5\x9f\xd7\xca\xb5\xee\xf9\xa9\xe1\x5b\xa9\x81\x60\x54\x8a\xe0\x26\x29\x73\xa8\x8f\x77\xf7\xd4\xfd\xdc\xab\x90\x6d\xea\xe1\x0d\x6c\xd7\x8d\x72\x53\xbd\x02\x0e\x84\x57\x56\xb1\x69\xf3\xf4\xee\x28\xa4\x45\xf2\xad\x83\xe0\xbe\xbd\x3c\xa4\x44\xa3\x49\x94\xa8\xcd\x7d\x4f\x8b\xbe\x26\x4f\x51\xd9\x5a\x44\xab\x25\x4f\x2b\x43\xcd\xeb\x03\x69\xb9\xb2\x53\xcc\xbb\xa7\xa6\x06\xaf\xb7\x6c\x81\xc0\x24\x24\x44\x19\x44\x4c\x08\x20\x95\xbf\x17\x34\x4c\x8b\xad\xbb\x33\x9c\xcf\x0f\x9a\x4f\xbd\xfc\x46\x43\x5b\x29\x6d\xcd\xdc\x69\x6b\xd2\xb6\x9a\xfb\x0a\x25\xb0\xcf\x7a\xcb\xff\x18\x3a\x78\x18\x7f\x13\x3c\xe4\x25\xef\xe4\xf1\xbf\x00\x00\x00\xff\xff\x09\x07\x8f\xf1\x2c\x12\x00\x00")
+var _defaulthttppatternJson = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xe4\x57\x4d\x6f\x1b\x37\x10\xbd\xeb\x57\x4c\x08\x23\xb6\x00\x67\xed\xa6\xbd\x54\x80\x0e\x92\x6b\x34\x4d\x6b\x34\x88\x95\xe6\x50\xe4\x40\xed\x8e\xb4\xb4\x56\xcb\x2d\x39\x94\xab\xd6\xfe\xef\x05\x29\xee\x97\x76\x25\xad\x62\xbb\x40\x51\xd8\xf0\x07\x39\x1c\xbe\xf7\x66\xf8\x28\xfe\xdd\x03\x60\x29\x5f\x22\x1b\x00\xfb\x01\x67\xdc\x24\xf4\x8e\x28\xfb\xc0\x89\x50\xa5\xec\xdc\xce\x6b\xc2\x4c\xb3\x01\xfc\xde\x03\x00\xb0\x4b\xec\x17\x13\x33\xbb\xe8\x24\x08\x65\x3a\x0b\x8c\xc6\x8f\x9c\xf0\x17\xb1\x14\x84\x0a\x86\x43\x20\x65\xd0\xad\x77\xc1\x1a\xd5\x4a\x84\x6e\x9b\x4a\x5c\x39\x2f\xd2\xcc\x10\x1b\x14\xd9\x01\x18\xc9\x05\xa6\x76\xc1\x3c\x91\x53\x9e\x30\x3f\xf3\xe8\x7e\x3f\x9e\xef\x07\xf3\xfe\xf3\x64\x2f\x88\xf7\x9f\x27\xbf\xf1\x44\x44\x9c\x64\x47\x14\xc3\x93\x20\xe3\xeb\x44\xf2\x28\x88\x91\x47\xa8\x74\x30\x32\x14\x4b\x25\xfe\xe2\x24\x64\x5a\x64\xb1\x6c\xc5\x3c\x15\xe9\xfc\x06\x29\x96\x91\x5f\xec\x64\xba\xbb\xa7\xdb\xda\x5c\x65\xd1\x02\xd7\x5b\xa1\x3f\xe3\xba\x9a\x95\x9b\xed\x5c\x23\x53\xcb\x20\xb4\xde\xca\xf0\x93\xd6\xd5\x00\x6d\xa6\x5b\x01\xb7\x66\x7a\x9c\xb0\x57\x42\x85\x46\xd0\x58\x21\x5f\x1c\x28\x74\x3d\x94\xed\x49\x7f\xd6\x28\xdc\x8c\x27\x1a\xe1\xe1\x01\x4e\x82\x6a\xa9\x02\x69\x28\x33\xa4\x83\x95\x1d\xc9\x77\xef\xc3\xeb\xd7\xe0\x53\x2c\x9d\xe6\x76\xe6\xf4\xc7\xeb\xc9\x29\x83\x36\x64\xb6\xc5\xc7\x3c\x5c\x60\x1a\x8d\x4a\xe8\x31\x4f\xc8\xa3\xa9\x06\x04\xa8\x94\x54\xf0\x6a\x08\xa9\x48\xdc\x56\xaf\xdc\x48\x20\x74\x8a\xe4\xfe\x6c\x5d\xd1\x7f\x69\xc6\x67\x4d\xca\x1f\x3e\x4d\x4e\x37\xaa\x35\x66\x7e\xbd\xdd\x39\x35\x9a\x5c\xbd\x3b\xed\xb7\xd6\xb0\x42\x6b\xbc\xf7\x9c\x84\x32\x25\x4c\xa9\xda\x5e\xf9\x90\x0f\x7a\x3c\x20\xf4\xf8\x68\xa1\xc7\x1d\x84\x3e\xd4\xb9\x76\x9b\xb3\xd6\xfa\x79\x20\xbb\x7b\xab\xff\xf0\x00\xfb\x28\xbc\x40\x8d\xfa\xfd\x2e\x27\x6d\x5f\x9d\x64\x86\x6a\xe3\x57\x03\x60\xa1\x34\x29\xa1\x7a\x0e\x07\xd8\xa3\xe3\xf0\xab\x75\x1c\xfe\x47\x74\x54\xa8\xb1\xec\xf3\x5e\xfe\xf3\x8b\xdd\x83\x29\xd4\x99\x4c\x35\xee\xb9\x42\x2b\x57\x62\x71\xde\x13\x7b\x95\x7e\x44\x1e\xc6\x58\x1c\xfb\x12\x91\xeb\x33\x36\x70\xa3\xc5\xe0\x66\x69\x1d\x67\x28\x23\x7b\x90\xbf\x7b\xfb\x7d\x1e\x06\xc0\x22\x4e\xbc\x16\x66\x65\x20\x4e\x46\xe7\x17\x34\x38\x38\x70\xfd\x67\x88\x18\x61\x04\x6f\x60\x12\x23\x78\xa9\x60\x2d\x0d\xc4\x7c\x85\xa0\xf0\x0f\x83\x9a\x30\x02\xa1\x41\xae\x50\x01\xc5\x08\x3c\x49\xe4\x3d\x46\xe0\x28\x04\x05\x68\xfb\xcd\x54\x4e\xd5\x3b\x45\x1b\x75\x7f\xbf\xe6\x82\xe6\x92\x76\x6d\x50\x6f\xa8\x79\x57\x1e\xf2\x53\x67\xbc\x4f\x50\xf6\xf2\x9b\x03\xca\xba\x8c\x37\xa8\x35\x9f\xa3\x67\xdd\x8a\xa8\x16\x57\xe6\x04\x60\xce\xfb\xdd\xa9\xed\x90\xa6\x19\xfc\x95\x3a\xee\x3e\xe8\x27\x41\x7d\xae\xd8\x9b\x94\xc8\xb2\x67\xe9\xd7\xcb\x6f\xbb\xa8\x6a\xe5\x0c\x37\x50\x60\xea\x71\x7a\x0c\xc7\xb1\x3e\x6b\xb4\xcf\xb3\x7d\x02\x39\xef\x6d\x21\x76\x99\xbb\x88\xf0\xf6\xf2\xb2\x29\x02\x1b\xd6\xac\x72\x54\x00\x72\x87\xfa\x5f\xa2\x7a\xd6\xe4\xfa\x14\x3b\x7e\x61\x8d\xc6\x47\x68\x74\x7c\xa3\xb6\xed\x7f\xed\xb2\xd4\x37\x2a\x2e\x04\xef\xa2\x2d\xf7\x41\xfe\x12\xab\x98\x62\x29\x4d\x84\x3a\x54\x22\xcb\xef\x1c\x1b\x03\xc9\x76\x90\x42\x57\xe3\xb9\xa0\xd8\x4c\x83\x50\x2e\x2f\x32\x25\xef\x30\xa4\x37\xb3\x44\xce\xe5\xc5\x52\x84\x4a\xce\x39\xe1\x3d\x5f\x5f\xf0\x90\xc4\x4a\xd0\xfa\xc2\x9a\x72\x23\x95\x46\x22\x91\xce\x75\x9d\x76\x52\xf1\x6e\x57\xe8\xd2\xd0\xeb\x74\xcf\xdb\xa9\x55\x1b\x6c\x27\x37\x1f\x81\xa0\xe5\x12\xc1\x3d\x04\xf5\x53\x39\xde\xdd\x13\xdb\x8b\xac\x6e\x69\x3b\xb1\x5d\x6d\xd9\x8d\xaf\xe6\x53\xe1\x79\x17\x9b\x6e\xef\xde\x5e\x85\xe5\xa6\xf9\x8a\x22\xb8\xff\xf3\x35\xf6\xd5\x1a\x2b\xd4\xb1\x4c\x6a\x0f\xc6\x72\xb0\x1a\x29\x96\x28\x4d\xad\xa2\xf9\x50\x25\x2a\x43\x25\xea\x2f\x59\x3f\xd2\xa9\xe6\x55\xbb\xda\xa9\xeb\x0d\x5f\x20\xf0\x14\x62\xa2\x0c\x42\x9e\x24\x40\xd2\x7e\xd0\x50\x30\xdd\x7c\xb8\xee\xae\xb0\x7d\x78\x28\x31\x2d\xc5\x55\xa8\x4b\x3a\xbb\x24\x6d\x3c\xd6\x97\x8d\x27\xba\x51\xa2\x1a\xe0\x81\x7d\x52\xc9\xd1\x3a\x8c\xff\x17\x3a\xf4\x00\xbe\xf4\x1e\xff\x19\x00\x72\xf7\xc9\x12\x65\x12\x00\x00")
 
 func defaulthttppatternJsonBytes() ([]byte, error) {
 	return bindataRead(
@@ -82,7 +82,7 @@ func defaulthttppatternJson() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "DefaultHttpPattern.json", size: 4652, mode: os.FileMode(420), modTime: time.Unix(1551218260, 0)}
+	info := bindataFileInfo{name: "DefaultHttpPattern.json", size: 4709, mode: os.FileMode(420), modTime: time.Unix(1792404596, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
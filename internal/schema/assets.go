@@ -66,7 +66,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _schemaJson = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x58\xcd\x8e\x9b\x30\x10\xbe\xef\x53\x20\x6f\x4f\x55\x77\x69\xa5\x9e\xf2\x06\x3d\x54\x5d\xb5\xc7\x6a\x0f\x0e\x0c\xc4\x2b\xb0\xbd\xe3\xa1\x55\x54\xe5\xdd\x2b\xfe\x1c\x08\x60\x87\x40\xfa\x23\x85\x43\x0e\xfe\x3e\x3c\xf6\xfc\x7c\x33\xe4\xd7\x5d\x10\x04\x01\x7b\x63\xa2\x1d\xe4\x9c\x6d\x02\xb6\x23\xd2\x9b\x30\x7c\x31\x4a\x3e\xd4\xab\x8f\x0a\xd3\x30\x46\x9e\xd0\xc3\xfb\x8f\x61\xbd\x76\xcf\xde\x35\x6f\x22\x24\xe5\x6b\xf7\x61\x0c\x89\x90\x82\x84\x92\x26\xfc\x2c\x22\x54\x29\x27\xf8\xc9\xf7\x2d\xb3\x83\xb3\x4d\x50\x1b\xae\x80\x1e\xb9\x8b\x54\x28\xc2\x6b\x21\x10\x62\xb6\x09\xbe\xf7\x90\x0a\x95\x3c\x87\xc6\x40\x6f\xdd\x10\x68\xc3\x7a\xeb\xcf\x7d\x1a\xd3\xa8\x34\x20\x09\x30\x03\xa3\xc7\xad\xc7\x90\x0a\xa5\xbd\x2e\x51\x66\x08\x85\x4c\xd9\x80\x74\x18\x39\x14\x82\xd1\x4a\x9a\x09\x83\x15\x45\x10\xe4\xd3\x70\xb0\x28\x56\xe3\xbb\x8d\xc6\xef\x6b\x73\xd2\xe1\xbd\x26\xee\xd6\xf3\x09\x47\xe4\xfb\xf3\x5c\x62\x00\x7f\x88\xe8\x3f\xf0\xc8\xb7\xfa\xa0\xf3\x1c\x52\x48\xf1\x5a\xc0\xa7\xe6\x06\x84\x05\xac\xe8\xb9\x2a\xc3\xff\x79\xb7\x11\xe8\x79\x3e\xcb\x85\x6c\x1d\xf6\xe1\x42\x6f\xdd\x39\xec\x30\x1e\xc7\xd5\xd9\x78\xf6\xd4\x15\x80\x84\x67\xe6\x24\x3a\xd6\x8e\xda\xbe\x40\x44\x47\x43\x9d\x2d\xd9\x97\x82\x74\x41\x73\x65\x2b\xe6\xc4\x97\xa9\x53\xa4\x62\x87\x3a\x4d\x5c\xd2\x9f\x81\xc3\xb3\x1e\xf7\xac\xbc\xed\xc8\x80\xad\x52\x19\x70\xe9\xa2\x08\x49\x90\x02\xba\x28\xb2\xc8\xb7\x3e\x46\x96\xb9\xf0\x26\x5c\x0e\xc6\x94\x68\x97\xcf\xf3\x59\xd5\x57\x05\xf0\xe6\xfe\x31\x7c\x75\xf7\xff\xc9\x72\xb6\x8d\x6f\x66\x41\x03\xa2\xc2\x65\x15\x5d\x6f\xe1\x1d\x38\xda\x30\x9f\x95\xa6\x22\x59\x77\x82\x51\xe3\x6a\x67\xf1\xd5\x5a\xca\x44\x3b\x69\xd4\xf6\xef\xe6\x48\x3b\x0a\xac\x34\xaa\x96\x17\x5d\x94\x38\x31\x98\x08\x85\x2e\x6f\xb8\x6e\xb4\xaf\x31\x01\xaf\x9c\x90\x06\x88\x84\x4c\x1d\x83\x90\xe6\x44\x80\xf2\xc9\xed\x44\x4b\x7f\x7c\xeb\xc4\x83\x8b\xe4\xfd\xf4\x8a\xd3\x32\x7f\xb4\xe1\x91\x7b\x4b\xf4\xcb\xbe\xa5\xfa\xe5\xdf\x52\xbd\x6d\xa0\xc3\x74\xb6\x03\xcb\xf3\xb6\x05\xcb\x74\xb5\x87\xf6\x19\xb6\x89\xf6\x39\x5c\xf4\xc9\x72\x5a\xef\xe3\xbb\x5d\x5b\x58\xca\x61\x79\xa6\xaa\x98\xb1\xef\x92\xb9\x0a\xb2\xe3\x99\x43\xd4\x2f\xa9\xcb\xb5\x1b\x8f\x90\xce\xbe\x73\x2b\x72\x4b\xbd\x15\xf9\xc0\x96\xb7\xc8\xa7\xff\x98\x58\x90\xc5\x57\x57\x8e\xbb\xfa\xf7\xf0\x3b\x00\x00\xff\xff\x9b\xe3\x98\x29\xc4\x13\x00\x00")
+var _schemaJson = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x58\xc1\x6e\xdb\x3c\x0c\xbe\xfb\x29\x0c\xf6\x3f\xfd\x68\xeb\x0d\xd8\x29\x6f\xb0\x43\xb1\x61\x3b\x16\x3d\xa8\x36\xe3\xaa\xb0\x25\x95\xa2\x37\x04\x43\xde\x7d\x50\x62\x07\x71\x2c\xa9\x76\xec\x16\x2b\x90\xd0\x27\x89\x12\xa5\xef\x23\x3f\x49\xf9\x93\xa4\x69\x9a\xc2\x7f\x36\x7f\xc2\x5a\xc0\x2a\x85\x27\x66\xb3\xca\xb2\x67\xab\xd5\xcd\xbe\xf5\x56\x53\x99\x15\x24\xd6\x7c\xf3\xe9\x4b\xb6\x6f\xbb\x82\xeb\x76\x24\xe1\xda\x0d\xbb\xca\x0a\x5c\x4b\x25\x59\x6a\x65\xb3\x3b\x99\x93\x2e\x05\xe3\x6f\xb1\xe9\x3c\x8f\xfa\x61\x95\xee\x03\x3b\x83\x9e\xf3\x71\x8f\x33\x20\x7c\x69\x24\x61\x01\xab\xf4\xbe\xd7\xe3\x3e\x50\xa2\xc6\x36\xc0\xb1\x81\x65\x34\x16\x7a\xed\x0f\x7d\x37\x30\xa4\x0d\x12\x4b\xec\x2f\xa7\xb3\xfd\xd4\xbe\x1e\x67\xc0\x1b\xe3\x7a\xc1\x32\x49\x55\xf6\x23\x39\xdb\x7a\x16\x45\x68\x8d\x56\x36\x10\xd0\x7d\x20\x19\xeb\x70\xf7\x3c\xae\x7c\xbf\x00\x7f\x3f\xda\x95\x0e\xf7\x15\xd8\x5b\x0f\x13\x41\x24\x36\xe3\x20\xb1\x48\xbf\x64\xfe\x01\x10\xf9\xb9\x5f\xe8\x34\x40\x1a\x25\x5f\x1a\xfc\xda\xee\x80\xa9\xc1\x05\x91\xdb\x65\xf8\x3f\x0f\x1b\xa3\x99\x86\x59\x2d\x55\x07\xd8\xe7\x33\xd1\x4a\x22\x71\x40\x14\xc5\x8e\x52\x51\x7d\x3f\x16\x80\xb5\xa8\xec\x09\x3b\x87\x38\xfa\xf1\x19\x73\x86\xc4\x33\x25\xdc\x49\x22\x4d\x53\x65\xab\x4d\xfb\x79\x02\x95\xeb\xda\x08\x1a\xa1\x51\x8f\x5a\x57\x28\x54\x3f\x9a\x07\x1b\xf7\x81\x41\xca\x51\xb1\x28\x47\x4c\xac\x9a\xfa\x11\x69\x52\xa5\xcf\x50\xd4\x24\x12\x62\x69\x5a\xbf\x35\x6c\x1a\x9e\x4a\x6b\x21\x58\xcc\xe5\xb4\x88\xe0\x1e\xd8\xe4\xeb\xc2\x32\x5c\x6b\xf7\x6b\x8b\xc8\x3f\xdc\xd9\x21\x7b\x22\x2e\x52\x31\x96\x48\x31\x97\x36\x55\xa2\x1e\x55\x15\x9b\xa1\xa5\x2b\xe2\x11\xca\x1c\x67\x0f\x83\xd6\xed\x75\x80\xc0\x0b\xfc\xef\x02\x7f\x12\x21\x63\xe9\x72\x3e\xdc\x67\x26\x16\x34\xee\xc4\x7d\x56\x45\xa3\xf7\x7c\x18\xac\xbe\xa3\x79\xe0\x75\x82\x8c\xfb\x40\xae\x5f\x9f\x30\xc4\x86\x6f\x3e\xed\x57\xbb\xe5\x6f\x0a\x81\x5b\x42\xab\xb6\x83\x21\xdb\x24\xb2\xf2\xa5\x73\xa4\xbb\xe1\x4d\x4c\x91\xd0\x0b\xc4\x6d\x74\x56\xe2\x14\x68\x73\x92\xc6\xe9\xfd\xb2\x6c\xbf\xc5\xc3\x66\xe1\x84\xb4\xc8\x2c\x55\xe9\x47\xc6\x19\x18\xc1\x8c\xa4\x7a\xcc\xfb\x5d\x9d\xc1\xed\xff\xd1\xfe\xf3\xe4\xfd\x74\x8b\xf7\x49\xc0\x61\xbc\xdc\x4f\x90\xfd\x09\xf2\x3f\xfe\x18\x18\x7b\x1c\x74\xbf\xae\xa2\x46\x78\x86\xf8\x8f\x1f\x13\x7e\x29\x88\xe4\x4e\xb4\xde\xfd\xb3\xbd\xb5\xb0\xb8\x37\xd0\x44\x55\x59\xe4\x81\xf0\x24\xaa\x88\xa8\x9f\x53\x97\x4b\x1f\x3c\x52\x45\xcf\x9d\x4b\x91\x5f\x8a\x7c\x46\x91\x0f\xc7\x41\xed\x7f\xae\xbf\xdb\x4d\xa7\xfd\xbb\x20\x19\xb1\xc7\x8f\xf0\x62\x4e\xd2\x34\x4d\xb7\xc9\xf6\xef\x00\x84\xce\xc1\x4d\x47\x16\x00\x00")
 
 func schemaJsonBytes() ([]byte, error) {
 	return bindataRead(
@@ -81,7 +81,7 @@ func schemaJson() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "schema.json", size: 5060, mode: os.FileMode(420), modTime: time.Unix(1540501296, 0)}
+	info := bindataFileInfo{name: "schema.json", size: 5703, mode: os.FileMode(420), modTime: time.Unix(1792401888, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -0,0 +1,102 @@
+// Package watch loads files which are reloaded when they are modified
+package watch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	logger "github.com/project-flogo/core/support/log"
+)
+
+// CheckInterval is the minimum interval between checks for changes of a watched file
+var CheckInterval = time.Second
+
+var log = logger.ChildLogger(logger.RootLogger(), "watch")
+
+// Parser parses the contents of a file
+type Parser func(data []byte) (interface{}, error)
+
+// File is a file which is parsed again when it is modified
+type File struct {
+	path  string
+	kind  string
+	parse Parser
+
+	sync.RWMutex
+	value    interface{}
+	modified time.Time
+	checked  time.Time
+}
+
+// NewFile creates a new watched file and loads it, kind describes the file in errors
+func NewFile(path, kind string, parse Parser) (*File, error) {
+	file := &File{
+		path:  path,
+		kind:  kind,
+		parse: parse,
+	}
+	err := file.Load()
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Load loads and parses the file
+func (f *File) Load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	value, err := f.parse(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s %s: %v", f.kind, f.path, err)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	f.value, f.modified, f.checked = value, info.ModTime(), time.Now()
+	return nil
+}
+
+// Value returns the parsed contents, checking the file for changes at most once per CheckInterval
+func (f *File) Value() interface{} {
+	f.RLock()
+	check := time.Since(f.checked) > CheckInterval
+	f.RUnlock()
+	if check {
+		f.Reload()
+	}
+
+	f.RLock()
+	defer f.RUnlock()
+	return f.value
+}
+
+// Reload reloads the file if it has been modified, keeping the current contents on failure
+func (f *File) Reload() {
+	f.Lock()
+	f.checked = time.Now()
+	modified := f.modified
+	f.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		log.Warnf("unable to check %s %s: %v", f.kind, f.path, err)
+		return
+	}
+	if info.ModTime().Equal(modified) {
+		return
+	}
+	err = f.Load()
+	if err != nil {
+		log.Warnf("keeping the previous contents, unable to reload %s %s: %v", f.kind, f.path, err)
+	}
+}
@@ -0,0 +1,56 @@
+package watch
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "file.txt")
+
+	parse := func(data []byte) (interface{}, error) {
+		if len(data) == 0 {
+			return nil, errors.New("empty")
+		}
+		return string(data), nil
+	}
+	modified := time.Now()
+	write := func(content string) {
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		modified = modified.Add(time.Minute)
+		assert.Nil(t, os.Chtimes(path, modified, modified))
+	}
+
+	_, err = NewFile(path, "test file", parse)
+	assert.NotNil(t, err, "missing files should fail")
+	write("")
+	_, err = NewFile(path, "test file", parse)
+	assert.Equal(t, "invalid test file "+path+": empty", err.Error())
+
+	write("a")
+	file, err := NewFile(path, "test file", parse)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", file.Value())
+
+	write("b")
+	assert.Equal(t, "a", file.Value(), "the file should not be checked before the interval")
+	file.Reload()
+	assert.Equal(t, "b", file.Value())
+
+	write("")
+	file.Reload()
+	assert.Equal(t, "b", file.Value(), "invalid files should keep the previous contents")
+
+	assert.Nil(t, os.Remove(path))
+	file.Reload()
+	assert.Equal(t, "b", file.Value(), "missing files should keep the previous contents")
+}